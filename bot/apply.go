@@ -0,0 +1,177 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+// WritePlans saves plans for a later apply
+func WritePlans(path string, plans []Plan) (err error) {
+	data, err := json.MarshalIndent(plans, "", "  ")
+	if err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// ReadPlans loads plans saved by WritePlans
+func ReadPlans(path string) (plans []Plan, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &plans)
+	if err != nil {
+		return nil, errors.New("Invalid plan file: " + err.Error())
+	}
+
+	return
+}
+
+// withinTolerance checks that b differs from a by at most pct percent of the larger one
+func withinTolerance(a, b, pct float64) bool {
+	return math.Abs(a-b) <= pct/100*math.Max(math.Abs(a), math.Abs(b))+1e-8
+}
+
+// checkDrift verifies that the plan can still be executed on the current market:
+// balances and offers it was based on must not have changed beyond tolerance,
+// offers to cancel must still be active and the lends must fit the funds
+func checkDrift(plan Plan, m strategy.Market, tolerancePct float64) (err error) {
+	if plan.Snapshot == nil {
+		return errors.New("Plan has no market snapshot")
+	}
+
+	snap := plan.Snapshot
+	cur := marketSnapshot(m, time.Now().UTC())
+
+	var problems []string
+	if cur.Hash != snap.Hash {
+		if !withinTolerance(snap.WalletAmount, cur.WalletAmount, tolerancePct) {
+			problems = append(problems, "wallet amount changed from "+formatFloat(snap.WalletAmount)+" to "+formatFloat(cur.WalletAmount))
+		}
+
+		snapFunds := snap.WalletAvailable
+		for _, o := range snap.Offers {
+			snapFunds += o.Amount
+		}
+
+		curFunds := m.Wallet.Available + m.Offered()
+		if !withinTolerance(snapFunds, curFunds, tolerancePct) {
+			problems = append(problems, "available and offered funds changed from "+formatFloat(snapFunds)+" to "+formatFloat(curFunds))
+		}
+	}
+
+	// Funds that will be free for lending after the plan's cancellations
+	free := m.Wallet.Available
+	var lent exchange.Decimal
+	for _, a := range plan.Actions {
+		switch a.Action {
+		case strategy.ActionCancelAll:
+			problems = append(problems, "plan cancels all offers instead of the bot's own offers by ID")
+		case strategy.ActionCancel:
+			found := false
+			for _, o := range m.Offers {
+				if o.ID == a.OfferID {
+					found = true
+					free += o.RemainingAmount
+				}
+			}
+
+			if !found {
+				problems = append(problems, "offer "+strconv.Itoa(a.OfferID)+" to cancel is no longer active")
+			}
+		case strategy.ActionLend:
+			lent = lent.Add(a.Amount)
+		}
+	}
+
+	if lent.Cmp(exchange.DecimalFromFloat(free)) > 0 {
+		problems = append(problems, "planned lends of "+lent.String()+" exceed free funds of "+formatFloat(free))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Market drifted since the plan: " + strings.Join(problems, "; "))
+	}
+
+	return
+}
+
+// ApplyPlans executes saved plans, but only if none of the markets drifted beyond
+// the tolerance (%) and all of them pass the guardrails
+func (b *Bot) ApplyPlans(plans []Plan, tolerancePct float64, dryRun bool) (err error) {
+	confs := make([]config.Config, len(plans))
+	for i, plan := range plans {
+		conf, ok := b.account(plan.Account)
+		if !ok {
+			return errors.New("Unknown account in plan: " + plan.Account)
+		}
+		conf.Log = b.logger()
+
+		if strings.ToLower(conf.Bitfinex.ActiveWallet) != plan.Currency {
+			return errors.New("Plan of " + plan.Account + " is for " + plan.Currency + ", but the active wallet is " + conf.Bitfinex.ActiveWallet)
+		}
+
+		if b.State.paused(conf) {
+			return errors.New("Lending is paused for " + plan.Account)
+		}
+
+		conf.Log.Println("Checking plan of " + plan.Account + " created " + plan.Created.Local().Format("2006-01-02 15:04:05") + "...")
+
+		m, err := fetchMarket(conf, nil)
+		if err != nil {
+			return err
+		}
+
+		err = checkDrift(plan, m.Market, tolerancePct)
+		if err != nil {
+			return errors.New(plan.Account + ": " + err.Error())
+		}
+
+		err = guardPlan(conf, b.State.Account(conf.AccountName()), plan)
+		if err != nil {
+			return errors.New(plan.Account + ": " + err.Error())
+		}
+
+		confs[i] = conf
+	}
+
+	for i, plan := range plans {
+		conf := confs[i]
+		stats := &RunStats{}
+		as := b.State.Account(conf.AccountName())
+
+		conf.Log.Println("Applying plan of " + plan.Account + "...")
+		err = b.executePlan(conf, plan, stats, dryRun)
+
+		brief := plan.brief()
+		as.LastRun = &RunResult{Time: time.Now().UTC(), DryRun: dryRun, Plan: &brief}
+		as.Digest.OffersPlaced += stats.OffersPlaced
+		as.Digest.OffersCancelled += stats.OffersCancelled
+
+		if err != nil {
+			as.LastRun.Error = err.Error()
+			return errors.New("Failed to apply plan of " + plan.Account + ": " + err.Error())
+		}
+
+		as.planExecuted(plan, dryRun)
+	}
+
+	return
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,117 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func testMarket() strategy.Market {
+	return strategy.Market{
+		Currency: "usd",
+		Wallet:   bitfinex.WalletBalance{Amount: 1000, Available: 400},
+		Offers: bitfinex.Offers{
+			bitfinex.Offer{ID: 1, Rate: 36.5, Period: 2, RemainingAmount: 300},
+			bitfinex.Offer{ID: 2, Rate: 40, Period: 2, RemainingAmount: 300},
+		},
+		Lendbook: bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 5000}}},
+		Info:     exchange.DefaultCurrencyInfo(),
+		MinLoan:  50,
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	m := testMarket()
+	plan := Plan{Snapshot: marketSnapshot(m, time.Now()), Actions: strategy.PlanActions{
+		strategy.PlanAction{Action: strategy.ActionCancel, OfferID: 1},
+		strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("700"), YearlyRate: exchange.MustDecimal("36"), Period: 2},
+	}}
+
+	if err := checkDrift(plan, m, 0); err != nil {
+		t.Error("Unchanged market reported as drifted: " + err.Error())
+	}
+
+	// Snapshot hash does not depend on the time
+	if marketSnapshot(m, time.Now().Add(time.Hour)).Hash != plan.Snapshot.Hash {
+		t.Error("Snapshot hash depends on the time")
+	}
+
+	// Lendbook moves, balances are the same
+	moved := testMarket()
+	moved.Lendbook.Asks[0].Rate = 40
+	if err := checkDrift(plan, moved, 0); err != nil {
+		t.Error("Lendbook change reported as drifted: " + err.Error())
+	}
+
+	// Small partial fill within tolerance
+	filled := testMarket()
+	filled.Offers[1].RemainingAmount = 295
+	filled.Wallet.Amount = 995
+	if err := checkDrift(plan, filled, 1); err != nil {
+		t.Error("Change within tolerance reported as drifted: " + err.Error())
+	}
+
+	if err := checkDrift(plan, filled, 0.1); err == nil || !strings.Contains(err.Error(), "funds changed") {
+		t.Errorf("Change beyond tolerance not reported: %v", err)
+	}
+
+	// Offer to cancel is gone
+	gone := testMarket()
+	gone.Offers = gone.Offers[1:]
+	if err := checkDrift(plan, gone, 100); err == nil || !strings.Contains(err.Error(), "no longer active") {
+		t.Errorf("Missing offer not reported: %v", err)
+	}
+
+	// Lends must fit the funds freed by the plan
+	plan.Actions[1].Amount = exchange.MustDecimal("800")
+	if err := checkDrift(plan, m, 0); err == nil || !strings.Contains(err.Error(), "exceed free funds") {
+		t.Errorf("Overspending plan not reported: %v", err)
+	}
+
+	plan.Actions = strategy.PlanActions{strategy.PlanAction{Action: strategy.ActionCancelAll}, strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("100")}}
+	if err := checkDrift(plan, m, 0); err == nil || !strings.Contains(err.Error(), "cancels all offers") {
+		t.Errorf("Plan cancelling all offers accepted: %v", err)
+	}
+
+	if err := checkDrift(Plan{}, m, 0); err == nil {
+		t.Error("Plan without snapshot accepted")
+	}
+}
+
+func TestPlanFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blb")
+	if err != nil {
+		t.Fatal("Failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "plan.json")
+	plans := []Plan{{Account: "acc1", Currency: "usd", Snapshot: marketSnapshot(testMarket(), time.Now()),
+		Actions: strategy.PlanActions{strategy.PlanAction{Action: strategy.ActionCancel, OfferID: 1}, strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("100"), YearlyRate: exchange.MustDecimal("36.5"), Period: 2}}}}
+
+	if err := WritePlans(path, plans); err != nil {
+		t.Fatal("Failed to write plan file: " + err.Error())
+	}
+
+	read, err := ReadPlans(path)
+	if err != nil {
+		t.Fatal("Failed to read plan file: " + err.Error())
+	}
+
+	if len(read) != 1 || read[0].Snapshot.Hash != plans[0].Snapshot.Hash || len(read[0].Actions) != 2 || read[0].Actions[1].Amount != exchange.MustDecimal("100") {
+		t.Error("Plan file did not round-trip")
+	}
+
+	if err := checkDrift(read[0], testMarket(), 0); err != nil {
+		t.Error("Plan read from file reported as drifted: " + err.Error())
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+// Package bot runs the lending strategies of the configured accounts on the exchange
+// and keeps their local state between runs. To run it from another program:
+//
+//	confs, err := config.Load("default.conf")
+//	if err != nil {
+//		return err
+//	}
+//
+//	return bot.Run(ctx, bot.Config{Accounts: confs, StateFile: "blb.state", Interval: 10 * time.Minute})
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Bot holds the configured accounts and their local state,
+// shared between scheduled runs and the control API
+type Bot struct {
+	sync.Mutex
+
+	Confs     config.Configs
+	State     *State
+	StateFile string
+
+	KillSwitch     string    // Order placement halts while this file exists
+	ReportCurrency string    // Currency idle funds are valued in, for alert rules
+	LogOutput      io.Writer // Where the log goes besides run log captures, stderr if nil
+
+	log *log.Logger
+}
+
+// RunResult ...
+type RunResult struct {
+	Time    time.Time
+	DryRun  bool
+	Skipped string   `json:",omitempty"`
+	Error   string   `json:",omitempty"`
+	Reason  string   `json:",omitempty"` // Why market data was rejected
+	Plan    *Plan    `json:",omitempty"`
+	Log     []string `json:",omitempty"`
+}
+
+// Config is what the bot runs with
+type Config struct {
+	Accounts       config.Configs
+	StateFile      string        // Local state, created on the first run
+	CurrencyCache  string        // Currency metadata cache file, not kept if empty
+	PriceTTL       time.Duration // How long prices are cached
+	KillSwitch     string        // Order placement halts while this file exists
+	ReportCurrency string        // Currency idle funds are valued in, defaults to usd
+	Explain        bool          // Record how each offer's amount, rate and period were derived
+	LogOutput      io.Writer     // Defaults to stderr
+
+	Interval time.Duration // Run repeats, 0 to run once (Run only)
+	DryRun   bool          // Only log the plans, without placing or cancelling offers (Run only)
+}
+
+// New loads the local state and connects the accounts to the exchange
+func New(cfg Config) (b *Bot, err error) {
+	st, err := loadState(cfg.StateFile)
+	if err != nil {
+		return nil, errors.New("Failed to load state file: " + err.Error())
+	}
+
+	currencies, err := exchange.LoadCurrencyRegistry(cfg.CurrencyCache)
+	if err != nil {
+		return nil, errors.New("Failed to load currency metadata cache: " + err.Error())
+	}
+
+	// Prices are public, so shared by all accounts
+	prices := exchange.NewPriceOracle(st.Prices, cfg.PriceTTL)
+
+	confs := append(config.Configs{}, cfg.Accounts...)
+	for i := range confs {
+		confs[i].Currencies = currencies
+		confs[i].Prices = prices
+		confs[i].API = bitfinex.New(confs[i].Bitfinex.APIKey, confs[i].Bitfinex.APISecret)
+		confs[i].ExtAPI = exchange.NewBitfinexExt(confs[i].Bitfinex.APIKey, confs[i].Bitfinex.APISecret)
+		confs[i].Explain = cfg.Explain
+		confs[i].Owned = &st.Account(confs[i].AccountName()).Owned
+	}
+
+	reportCurrency := cfg.ReportCurrency
+	if reportCurrency == "" {
+		reportCurrency = "usd"
+	}
+
+	b = &Bot{Confs: confs, State: st, StateFile: cfg.StateFile, KillSwitch: cfg.KillSwitch,
+		ReportCurrency: reportCurrency, LogOutput: cfg.LogOutput}
+	b.log = log.New(b.logOutput(), "", log.LstdFlags)
+
+	return b, nil
+}
+
+// Run runs the strategy of every account and updates their lend offers, then again every
+// Interval until the context is done. The local state is saved after every run.
+func Run(ctx context.Context, cfg Config) error {
+	b, err := New(cfg)
+	if err != nil {
+		return err
+	}
+
+	return b.RunEvery(ctx, cfg.Interval, true, cfg.DryRun)
+}
+
+// RunEvery runs all accounts, then again every interval (if positive) until the context is done
+func (b *Bot) RunEvery(ctx context.Context, interval time.Duration, updateLends, dryRun bool) (err error) {
+	for {
+		b.Lock()
+		err = b.RunOnce("", updateLends, dryRun)
+		b.Unlock()
+
+		if interval <= 0 {
+			return
+		}
+		if err != nil {
+			b.logger().Println("WARNING: Failed to save state file: " + err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SaveState writes the local state to the state file
+func (b *Bot) SaveState() error {
+	return b.State.save(b.StateFile)
+}
+
+func (b *Bot) account(name string) (conf config.Config, ok bool) {
+	for _, c := range b.Confs {
+		if c.AccountName() == name {
+			return c, true
+		}
+	}
+
+	return
+}
+
+// RunOnce runs the named account (or all accounts if name is empty) and saves the state
+func (b *Bot) RunOnce(name string, updateLends, dryRun bool) (err error) {
+	found := false
+	for _, conf := range b.Confs {
+		if name != "" && conf.AccountName() != name {
+			continue
+		}
+		found = true
+
+		logger := b.logger()
+		logger.Println("Using Bitfinex user API key: " + conf.Bitfinex.APIKey)
+
+		// Keep the run log for the dashboard
+		start := time.Now().UTC()
+		buf := &bytes.Buffer{}
+		conf.Log = log.New(io.MultiWriter(logger.Writer(), buf), logger.Prefix(), logger.Flags())
+
+		b.runAccount(conf, updateLends, dryRun)
+
+		as := b.State.Account(conf.AccountName())
+		r := RunResult{Time: start, DryRun: dryRun}
+		if as.LastRun != nil && !as.LastRun.Time.Before(start) {
+			r = *as.LastRun
+		}
+		r.Log = strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		as.recordRun(r)
+	}
+
+	if !found {
+		return errors.New("Unknown account: " + name)
+	}
+
+	return b.SaveState()
+}
+
+func (b *Bot) logOutput() io.Writer {
+	if b.LogOutput == nil {
+		return os.Stderr
+	}
+
+	return b.LogOutput
+}
+
+// logger returns the bot's own logger, so that run log captures never touch the standard logger
+func (b *Bot) logger() *log.Logger {
+	if b.log == nil {
+		return log.New(b.logOutput(), "", log.LstdFlags)
+	}
+
+	return b.log
+}
+
+func (b *Bot) runAccount(conf config.Config, updateLends, dryRun bool) {
+	logger := conf.Logger()
+	st := b.State
+	as := st.Account(conf.AccountName())
+	activeWallet := strings.ToLower(conf.Bitfinex.ActiveWallet)
+	metrics := map[string]float64{}
+
+	// Alert rules are evaluated on whatever could be collected
+	defer func() {
+		metrics[notify.MetricFailedRuns] = float64(as.FailedRuns)
+		evaluateRules(conf, as, activeWallet, metrics, time.Now().UTC())
+	}()
+
+	balance, err := conf.API.WalletBalances()
+	if err != nil {
+		logger.Println("WARNING: Failed to get wallet funds, skipping: " + err.Error())
+		as.FailedRuns++
+		as.LastRun = &RunResult{Time: time.Now().UTC(), DryRun: dryRun, Error: "Failed to get wallet funds: " + err.Error()}
+		sendEvent(conf, as, notify.Event{Type: notify.EventRunFailed, Account: conf.AccountName(), Message: "Failed to get wallet funds: " + err.Error()})
+		return
+	}
+
+	wallet := balance[bitfinex.WalletKey{"deposit", activeWallet}]
+	logger.Println("\tDeposit wallet: " +
+		strconv.FormatFloat(wallet.Amount, 'f', -1, 64) +
+		" " + activeWallet + " (swappable: " +
+		strconv.FormatFloat(wallet.Available, 'f', -1, 64) +
+		" " + activeWallet + ")")
+
+	metrics[notify.MetricIdleFunds] = wallet.Available
+
+	price, err := conf.Price(activeWallet, b.ReportCurrency)
+	if err != nil {
+		logger.Println("WARNING: Failed to value idle funds in " + b.ReportCurrency + ": " + err.Error())
+	} else {
+		metrics[notify.MetricIdleValue] = wallet.Available * price
+	}
+
+	lent, lentKnown := 0.0, false
+
+	// Only report loans filled since the last successful update
+	prevLoans, prevUpdated := as.Loans, as.LoansUpdated
+
+	err = UpdateLoans(conf, as)
+	if err != nil {
+		logger.Println("WARNING: Failed to update active loans: " + err.Error())
+	} else {
+		if !prevUpdated.IsZero() {
+			for _, e := range newLoanEvents(conf.AccountName(), prevLoans, as.Loans) {
+				as.Digest.LoansFilled++
+				as.Digest.AmountFilled += e.Value
+				sendEvent(conf, as, e)
+			}
+		}
+
+		lentKnown = true
+		for _, l := range as.Loans {
+			if l.Currency == activeWallet {
+				lent += l.Amount
+			}
+		}
+
+		if wallet.Amount > 0 {
+			metrics[notify.MetricUtilization] = lent / wallet.Amount * 100
+		}
+	}
+
+	err = UpdateInterest(conf, as)
+	if err != nil {
+		logger.Println("WARNING: Failed to update interest payments: " + err.Error())
+	}
+
+	dailyFRR := 0.0
+	lendbook, lendbookErr := conf.API.Lendbook(activeWallet, 0, 10000)
+	if lendbookErr != nil {
+		logger.Println("WARNING: Failed to get lendbook for FRR: " + lendbookErr.Error())
+	} else if dailyFRR = exchange.LendbookDailyFRR(lendbook); dailyFRR > 0 {
+		for _, e := range frrCrossEvents(conf.AccountName(), activeWallet, conf.Notifications.FRRLevels, as.LastFRR, dailyFRR) {
+			sendEvent(conf, as, e)
+		}
+
+		as.LastFRR = dailyFRR
+		metrics[notify.MetricFRR] = dailyFRR
+	}
+
+	configMetrics(conf, dailyFRR, metrics)
+
+	if updateLends && st.paused(conf) {
+		logger.Println("\tLending is paused, skipping strategy")
+		as.LastRun = &RunResult{Time: time.Now().UTC(), DryRun: dryRun, Skipped: "paused"}
+	} else if updateLends && b.killSwitchActive() {
+		logger.Println("\tKill switch " + b.KillSwitch + " present, skipping strategy")
+		as.LastRun = &RunResult{Time: time.Now().UTC(), DryRun: dryRun, Skipped: "kill switch"}
+	} else if updateLends {
+		stats := &RunStats{}
+
+		// A failed sweep leaves the funds where they are, the strategy still runs
+		err := sweep(conf, balance, dryRun)
+		if err != nil {
+			logger.Println("WARNING: " + err.Error())
+		}
+
+		// The strategy plans with the same lendbook as the FRR above
+		var book *bitfinex.Lendbook
+		if lendbookErr == nil {
+			book = &lendbook
+		}
+
+		blocked := false
+		plan, err := planStrategy(conf, book)
+		if err == nil {
+			err = guardPlan(conf, as, plan)
+			blocked = err != nil
+		}
+		if err == nil {
+			err = b.executePlan(conf, plan, stats, dryRun)
+		}
+
+		brief := plan.brief()
+		as.LastRun = &RunResult{Time: time.Now().UTC(), DryRun: dryRun, Plan: &brief}
+
+		if err != nil {
+			logger.Println("WARNING: Failed to execute strategy: " + err.Error())
+			as.FailedRuns++
+			as.LastRun.Error = err.Error()
+			as.LastRun.Reason = string(exchange.ReasonOf(err))
+			if !blocked {
+				sendEvent(conf, as, notify.Event{Type: notify.EventRunFailed, Account: conf.AccountName(), Currency: activeWallet, Message: "Failed to execute strategy: " + err.Error()})
+			}
+		} else {
+			as.FailedRuns = 0
+			as.planExecuted(plan, dryRun)
+		}
+
+		as.Digest.OffersPlaced += stats.OffersPlaced
+		as.Digest.OffersCancelled += stats.OffersCancelled
+	}
+
+	// Without the loans the point would show the funds as not lent at all
+	if lentKnown {
+		as.recordHistory(HistoryPoint{Time: time.Now().UTC(), Currency: activeWallet,
+			Total: wallet.Amount, Lent: lent, Idle: wallet.Available, DailyFRR: dailyFRR})
+	} else {
+		logger.Println("\tSkipping the history point, the lent amount is unknown")
+	}
+
+	if lendbookErr == nil {
+		recordMarket(conf, as, balance, lendbook, time.Now().UTC())
+	}
+
+	if as.Digest.Since.IsZero() {
+		as.Digest.Since = time.Now().UTC()
+	}
+
+	sendDigest(conf, as, balance, time.Now().UTC())
+}
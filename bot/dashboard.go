@@ -0,0 +1,338 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Self-contained HTML dashboard served next to the control API.
+// All data comes from the local state recorded on every run.
+
+package bot
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+const (
+	historyDays    = 30  // How long lent vs idle and FRR history is kept
+	maxRunHistory  = 50  // Number of recent runs kept with their log
+	maxRunLogLines = 200 // Log lines kept per run
+)
+
+// HistoryPoint is the active wallet state at the end of a run
+type HistoryPoint struct {
+	Time     time.Time
+	Currency string
+	Total    float64
+	Lent     float64
+	Idle     float64
+	DailyFRR float64 `json:",omitempty"`
+}
+
+// LadderOffer ...
+type LadderOffer struct {
+	Rate   float64 // %/year
+	Amount float64
+	Period int
+}
+
+// MarketSnapshot is the last seen lendbook and own offers in the active currency
+type MarketSnapshot struct {
+	Time     time.Time
+	Currency string
+	Balances []Balance
+	Lendbook []exchange.DepthLevel
+	Offers   []LadderOffer
+}
+
+// DashboardAccount ...
+type DashboardAccount struct {
+	Name     string
+	Strategy string
+	Currency string
+	Paused   bool
+	Market   *MarketSnapshot
+	History  []HistoryPoint
+	Runs     []RunResult
+}
+
+func (as *AccountState) recordHistory(p HistoryPoint) {
+	as.History = append(as.History, p)
+
+	cutoff := p.Time.AddDate(0, 0, -historyDays)
+	i := 0
+	for i < len(as.History) && as.History[i].Time.Before(cutoff) {
+		i++
+	}
+	as.History = as.History[i:]
+}
+
+func (as *AccountState) recordRun(r RunResult) {
+	if len(r.Log) > maxRunLogLines {
+		r.Log = r.Log[len(r.Log)-maxRunLogLines:]
+	}
+
+	as.Runs = append(as.Runs, r)
+	if len(as.Runs) > maxRunHistory {
+		as.Runs = as.Runs[len(as.Runs)-maxRunHistory:]
+	}
+}
+
+// recordMarket keeps the lendbook depth and own lend offers for the offer ladder
+func recordMarket(conf config.Config, as *AccountState, balance bitfinex.WalletBalances, lendbook bitfinex.Lendbook, now time.Time) {
+	activeWallet := strings.ToLower(conf.Bitfinex.ActiveWallet)
+
+	m := &MarketSnapshot{Time: now, Currency: activeWallet, Balances: BalancesList(balance)}
+
+	for _, o := range lendbook.Asks {
+		m.Lendbook = append(m.Lendbook, exchange.DepthLevel{Rate: o.Rate, Amount: o.Amount, FRR: o.FRR})
+	}
+	sort.SliceStable(m.Lendbook, func(i, j int) bool { return m.Lendbook[i].Rate < m.Lendbook[j].Rate })
+
+	offers, err := conf.API.ActiveOffers()
+	if err != nil {
+		// Keep the previous ladder rather than showing an empty one
+		if as.Market != nil {
+			m.Offers = as.Market.Offers
+		}
+	} else {
+		for _, o := range offers {
+			if strings.ToLower(o.Currency) == activeWallet && strings.ToLower(o.Direction) == bitfinex.LEND {
+				m.Offers = append(m.Offers, LadderOffer{Rate: o.Rate, Amount: o.RemainingAmount, Period: o.Period})
+			}
+		}
+	}
+
+	as.Market = m
+}
+
+func (b *Bot) dashboard() (accounts []DashboardAccount) {
+	for _, conf := range b.Confs {
+		as := b.State.Account(conf.AccountName())
+
+		accounts = append(accounts, DashboardAccount{
+			Name:     conf.AccountName(),
+			Strategy: conf.Strategy.Active,
+			Currency: strings.ToLower(conf.Bitfinex.ActiveWallet),
+			Paused:   b.State.paused(conf),
+			Market:   as.Market,
+			History:  as.History,
+			Runs:     as.Runs,
+		})
+	}
+
+	return
+}
+
+// NewServeMux serves the dashboard page and the control API
+func NewServeMux(b *Bot, token string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", newAPIHandler(b, token))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// The page holds no data, it is fetched from the API with the bearer token
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'")
+		w.Write([]byte(dashboardHTML))
+	})
+
+	return mux
+}
+
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>BitfinexLendingBot</title>
+<style>
+body { font-family: sans-serif; margin: 20px; color: #222; background: #fafafa; }
+h1 { font-size: 20px; }
+h2 { font-size: 18px; border-bottom: 1px solid #ccc; padding-bottom: 4px; margin-top: 32px; }
+h3 { font-size: 14px; margin: 16px 0 4px; }
+table { border-collapse: collapse; font-size: 13px; }
+td, th { padding: 2px 10px; text-align: right; }
+th { border-bottom: 1px solid #ccc; }
+td:first-child, th:first-child { text-align: left; }
+.grid { display: flex; flex-wrap: wrap; gap: 24px; }
+.muted { color: #888; font-size: 12px; }
+.paused { color: #b00; font-weight: bold; }
+.error { color: #b00; }
+pre { font-size: 12px; background: #fff; border: 1px solid #ddd; padding: 6px; overflow-x: auto; }
+svg { background: #fff; border: 1px solid #ddd; }
+svg text { font-size: 10px; fill: #555; }
+</style>
+</head>
+<body>
+<h1>BitfinexLendingBot</h1>
+<form id="auth">API token: <input type="password" id="token"> <button>Load</button> <span id="status" class="muted"></span></form>
+<div id="accounts"></div>
+<script>
+"use strict";
+
+function esc(s) {
+	return String(s).replace(/[&<>"']/g, function(c) {
+		return {"&": "&amp;", "<": "&lt;", ">": "&gt;", "\"": "&quot;", "'": "&#39;"}[c];
+	});
+}
+
+function num(v, d) {
+	return Number(v).toFixed(d === undefined ? 4 : d);
+}
+
+// chart draws series of [x, y] points as an SVG line chart
+function chart(series, o) {
+	var W = 560, H = 220, L = 60, R = 10, T = 10, B = 30;
+	var xs = [], ys = [0];
+	series.forEach(function(s) { s.points.forEach(function(p) { xs.push(p[0]); ys.push(p[1]); }); });
+	(o.markers || []).forEach(function(m) { xs.push(m.x); });
+	if (xs.length === 0) {
+		return '<p class="muted">No data yet</p>';
+	}
+
+	var x0 = Math.min.apply(null, xs), x1 = Math.max.apply(null, xs);
+	var y0 = Math.min.apply(null, ys), y1 = Math.max.apply(null, ys);
+	if (x1 === x0) { x1 = x0 + 1; }
+	if (y1 === y0) { y1 = y0 + 1; }
+
+	function sx(x) { return L + (x - x0) / (x1 - x0) * (W - L - R); }
+	function sy(y) { return H - B - (y - y0) / (y1 - y0) * (H - T - B); }
+
+	var svg = '<svg width="' + W + '" height="' + H + '">';
+	svg += '<line x1="' + L + '" y1="' + sy(y0) + '" x2="' + (W - R) + '" y2="' + sy(y0) + '" stroke="#999"/>';
+	svg += '<line x1="' + L + '" y1="' + T + '" x2="' + L + '" y2="' + (H - B) + '" stroke="#999"/>';
+	svg += '<text x="' + (L - 4) + '" y="' + (sy(y1) + 4) + '" text-anchor="end">' + esc(o.fy(y1)) + '</text>';
+	svg += '<text x="' + (L - 4) + '" y="' + sy(y0) + '" text-anchor="end">' + esc(o.fy(y0)) + '</text>';
+	svg += '<text x="' + L + '" y="' + (H - B + 14) + '">' + esc(o.fx(x0)) + '</text>';
+	svg += '<text x="' + (W - R) + '" y="' + (H - B + 14) + '" text-anchor="end">' + esc(o.fx(x1)) + '</text>';
+	if (o.xlabel) {
+		svg += '<text x="' + ((W + L) / 2) + '" y="' + (H - 4) + '" text-anchor="middle">' + esc(o.xlabel) + '</text>';
+	}
+
+	(o.markers || []).forEach(function(m) {
+		svg += '<line x1="' + sx(m.x) + '" y1="' + T + '" x2="' + sx(m.x) + '" y2="' + (H - B) + '" stroke="' + m.color + '" stroke-dasharray="3,2"/>';
+		svg += '<text x="' + (sx(m.x) + 2) + '" y="' + (T + 10) + '">' + esc(m.label) + '</text>';
+	});
+
+	series.forEach(function(s, i) {
+		var pts = s.points.map(function(p) { return sx(p[0]).toFixed(1) + ',' + sy(p[1]).toFixed(1); }).join(' ');
+		svg += '<polyline fill="none" stroke="' + s.color + '" stroke-width="1.5" points="' + pts + '"/>';
+		svg += '<rect x="' + (L + 8 + i * 90) + '" y="' + (T + 16) + '" width="10" height="3" fill="' + s.color + '"/>';
+		svg += '<text x="' + (L + 22 + i * 90) + '" y="' + (T + 20) + '">' + esc(s.name) + '</text>';
+	});
+
+	return svg + '</svg>';
+}
+
+function fdate(t) {
+	var d = new Date(t);
+	return (d.getMonth() + 1) + '/' + d.getDate() + ' ' + ('0' + d.getHours()).slice(-2) + ':' + ('0' + d.getMinutes()).slice(-2);
+}
+
+function balances(m) {
+	if (!m || !m.Balances) {
+		return '<p class="muted">No data yet</p>';
+	}
+	var h = '<table><tr><th>Wallet</th><th>Currency</th><th>Amount</th><th>Available</th></tr>';
+	m.Balances.forEach(function(b) {
+		h += '<tr><td>' + esc(b.Type) + '</td><td>' + esc(b.Currency) + '</td><td>' + num(b.Amount, 8) + '</td><td>' + num(b.Available, 8) + '</td></tr>';
+	});
+	return h + '</table><p class="muted">As of ' + esc(fdate(m.Time)) + '</p>';
+}
+
+function funds(a) {
+	var hist = a.History || [];
+	function pts(f) { return hist.map(function(p) { return [Date.parse(p.Time), p[f]]; }); }
+	return chart([
+		{name: 'Lent', color: '#2a7', points: pts('Lent')},
+		{name: 'Idle', color: '#d60', points: pts('Idle')},
+		{name: 'Total', color: '#888', points: pts('Total')}
+	], {fx: fdate, fy: function(v) { return num(v, 2); }});
+}
+
+function ladder(m) {
+	if (!m || !m.Lendbook || m.Lendbook.length === 0) {
+		return '<p class="muted">No data yet</p>';
+	}
+	var depth = [], total = 0;
+	m.Lendbook.forEach(function(l) {
+		depth.push([l.Rate / 365, total]);
+		total += l.Amount;
+		depth.push([l.Rate / 365, total]);
+	});
+	var markers = (m.Offers || []).map(function(o) {
+		return {x: o.Rate / 365, color: '#06c', label: num(o.Amount, 2) + ' / ' + o.Period + 'd'};
+	});
+	return chart([{name: 'Lendbook depth', color: '#888', points: depth}, {name: 'Own offers', color: '#06c', points: []}],
+		{fx: function(v) { return num(v) + '%'; }, fy: function(v) { return num(v, 0); }, markers: markers, xlabel: 'daily rate'});
+}
+
+function frr(a) {
+	var pts = (a.History || []).filter(function(p) { return p.DailyFRR > 0; }).map(function(p) { return [Date.parse(p.Time), p.DailyFRR]; });
+	return chart([{name: 'FRR %/day', color: '#a3c', points: pts}], {fx: fdate, fy: function(v) { return num(v) + '%'; }});
+}
+
+function runs(a) {
+	var rs = (a.Runs || []).slice().reverse();
+	if (rs.length === 0) {
+		return '<p class="muted">No runs yet</p>';
+	}
+	var h = '';
+	rs.forEach(function(r) {
+		var s = fdate(r.Time) + (r.DryRun ? ' (dry run)' : '');
+		if (r.Skipped) { s += ' skipped: ' + r.Skipped; }
+		if (r.Error) { s += ' <span class="error">error: ' + esc(r.Error) + '</span>'; }
+		h += '<details><summary>' + s + '</summary><pre>' + esc((r.Log || []).join('\n')) + '</pre></details>';
+	});
+	return h;
+}
+
+function render(accounts) {
+	var h = '';
+	(accounts || []).forEach(function(a) {
+		h += '<h2>' + esc(a.Name) + ' <span class="muted">' + esc(a.Strategy) + ' / ' + esc(a.Currency) + '</span>' +
+			(a.Paused ? ' <span class="paused">PAUSED</span>' : '') + '</h2>';
+		h += '<div class="grid"><div><h3>Balances</h3>' + balances(a.Market) + '</div>';
+		h += '<div><h3>Lent vs idle funds</h3>' + funds(a) + '</div>';
+		h += '<div><h3>Offer ladder vs lendbook depth</h3>' + ladder(a.Market) + '</div>';
+		h += '<div><h3>FRR history</h3>' + frr(a) + '</div></div>';
+		h += '<h3>Recent runs</h3>' + runs(a);
+	});
+	document.getElementById('accounts').innerHTML = h;
+}
+
+function load() {
+	var status = document.getElementById('status');
+	var xhr = new XMLHttpRequest();
+	xhr.open('GET', 'api/dashboard');
+	xhr.setRequestHeader('Authorization', 'Bearer ' + sessionStorage.getItem('blbToken'));
+	xhr.onload = function() {
+		if (xhr.status !== 200) {
+			status.textContent = 'Failed to load: HTTP ' + xhr.status;
+			return;
+		}
+		render(JSON.parse(xhr.responseText));
+		status.textContent = 'Updated ' + new Date().toLocaleTimeString();
+	};
+	xhr.send();
+}
+
+document.getElementById('auth').onsubmit = function(e) {
+	e.preventDefault();
+	sessionStorage.setItem('blbToken', document.getElementById('token').value);
+	load();
+};
+
+if (sessionStorage.getItem('blbToken')) {
+	load();
+}
+setInterval(function() { if (sessionStorage.getItem('blbToken')) { load(); } }, 60000);
+</script>
+</body>
+</html>
+`
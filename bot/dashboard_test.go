@@ -0,0 +1,90 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+func TestRecordHistory(t *testing.T) {
+	as := &AccountState{}
+	now := time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	as.recordHistory(HistoryPoint{Time: now.AddDate(0, 0, -historyDays-1)})
+	as.recordHistory(HistoryPoint{Time: now.AddDate(0, 0, -1)})
+	as.recordHistory(HistoryPoint{Time: now, Lent: 10})
+
+	if len(as.History) != 2 {
+		t.Fatal("Returned wrong number of history points (" + strconv.Itoa(len(as.History)) + ", expected: 2)")
+	}
+
+	if !as.History[1].Time.Equal(now) || as.History[1].Lent != 10 {
+		t.Error("Latest history point is not kept last")
+	}
+}
+
+func TestRecordRun(t *testing.T) {
+	as := &AccountState{}
+
+	for i := 0; i < maxRunHistory+5; i++ {
+		as.recordRun(RunResult{Error: strconv.Itoa(i), Log: make([]string, maxRunLogLines+1)})
+	}
+
+	if len(as.Runs) != maxRunHistory {
+		t.Fatal("Returned wrong number of runs (" + strconv.Itoa(len(as.Runs)) + ", expected: " + strconv.Itoa(maxRunHistory) + ")")
+	}
+
+	if as.Runs[len(as.Runs)-1].Error != strconv.Itoa(maxRunHistory+4) {
+		t.Error("Latest run is not kept last")
+	}
+
+	if len(as.Runs[0].Log) != maxRunLogLines {
+		t.Error("Run log was not truncated (" + strconv.Itoa(len(as.Runs[0].Log)) + " lines)")
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	b, cleanup := testBot(t)
+	defer cleanup()
+
+	as := b.State.Account("acc1")
+	as.recordHistory(HistoryPoint{Time: time.Now().UTC(), Currency: "btc", Total: 2, Lent: 1.5, Idle: 0.5, DailyFRR: 0.05})
+	as.Market = &MarketSnapshot{Lendbook: []exchange.DepthLevel{{Rate: 18.25, Amount: 100}}, Offers: []LadderOffer{{Rate: 20, Amount: 1, Period: 2}}}
+
+	mux := NewServeMux(b, "secret")
+
+	// The page itself needs no token and loads nothing external
+	rec := apiRequest(mux, "GET", "/", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<html>") {
+		t.Fatal("Dashboard page not served (" + strconv.Itoa(rec.Code) + ")")
+	}
+	if strings.Contains(rec.Body.String(), "http://") || strings.Contains(rec.Body.String(), "https://") {
+		t.Error("Dashboard page references external resources")
+	}
+
+	if rec := apiRequest(mux, "GET", "/api/dashboard", ""); rec.Code != http.StatusUnauthorized {
+		t.Error("Dashboard data served without token (" + strconv.Itoa(rec.Code) + ")")
+	}
+
+	rec = apiRequest(mux, "GET", "/api/dashboard", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatal("Returned wrong status (" + strconv.Itoa(rec.Code) + ", expected: 200)")
+	}
+
+	var accounts []DashboardAccount
+	if err := json.Unmarshal(rec.Body.Bytes(), &accounts); err != nil {
+		t.Fatal("Failed to decode dashboard data: " + err.Error())
+	}
+
+	if len(accounts) != 2 || accounts[0].Name != "acc1" || len(accounts[0].History) != 1 ||
+		accounts[0].Market == nil || len(accounts[0].Market.Offers) != 1 {
+		t.Error("Returned wrong dashboard data: " + rec.Body.String())
+	}
+}
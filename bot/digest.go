@@ -0,0 +1,116 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// DigestState accumulates account activity between daily digests
+type DigestState struct {
+	Since           time.Time
+	LastSent        time.Time
+	OffersPlaced    int
+	OffersCancelled int
+	LoansFilled     int
+	AmountFilled    float64
+}
+
+// digestDue returns true once per UTC day, after the configured hour
+func digestDue(lastSent time.Time, hour int, now time.Time) bool {
+	now = now.UTC()
+	if now.Hour() < hour {
+		return false
+	}
+
+	return lastSent.UTC().Format("2006-01-02") != now.Format("2006-01-02")
+}
+
+func buildDigest(account string, as *AccountState, balance bitfinex.WalletBalances, now time.Time) string {
+	d := as.Digest
+	since := d.Since
+	if since.IsZero() {
+		since = now.AddDate(0, 0, -1)
+	}
+
+	lines := []string{
+		"Daily digest for " + account,
+		since.UTC().Format("2006-01-02 15:04") + " - " + now.UTC().Format("2006-01-02 15:04 UTC"),
+		"",
+		"Deposit wallet balances:",
+	}
+
+	var keys []bitfinex.WalletKey
+	for k := range balance {
+		if k.Type == "deposit" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Currency < keys[j].Currency })
+
+	for _, k := range keys {
+		lines = append(lines, "  "+k.Currency+": "+strconv.FormatFloat(balance[k].Amount, 'f', -1, 64)+
+			" (available: "+strconv.FormatFloat(balance[k].Available, 'f', -1, 64)+")")
+	}
+
+	lines = append(lines, "",
+		"Offers placed: "+strconv.Itoa(d.OffersPlaced),
+		"Offers cancelled: "+strconv.Itoa(d.OffersCancelled),
+		"Loans filled: "+strconv.Itoa(d.LoansFilled)+" ("+strconv.FormatFloat(d.AmountFilled, 'f', -1, 64)+")",
+		"",
+		"Interest earned:")
+
+	interest := map[string]float64{}
+	for _, p := range as.Interest {
+		if !p.Time.Before(since) {
+			interest[p.Currency] += p.Amount
+		}
+	}
+
+	var currencies []string
+	for cur := range interest {
+		currencies = append(currencies, cur)
+	}
+	sort.Strings(currencies)
+
+	for _, cur := range currencies {
+		lines = append(lines, "  "+cur+": "+strconv.FormatFloat(interest[cur], 'f', 8, 64))
+	}
+
+	if len(currencies) == 0 {
+		lines = append(lines, "  none")
+	}
+
+	lines = append(lines, "")
+	if as.LastFRR > 0 {
+		lines = append(lines, "Current FRR: "+strconv.FormatFloat(as.LastFRR, 'f', -1, 64)+" %/day")
+	} else {
+		lines = append(lines, "Current FRR: unknown")
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// sendDigest emails the daily digest if it is due and starts a new accumulation period
+func sendDigest(conf config.Config, as *AccountState, balance bitfinex.WalletBalances, now time.Time) {
+	ec := conf.Notifications.Email
+	if ec.Host == "" || !ec.Digest || !digestDue(as.Digest.LastSent, ec.DigestHour, now) {
+		return
+	}
+
+	conf.Logger().Println("\tSending daily digest...")
+
+	err := ec.SendMail("[BLB] "+conf.AccountName()+": daily digest", buildDigest(conf.AccountName(), as, balance, now))
+	if err != nil {
+		conf.Logger().Println("\tWARNING: Failed to send daily digest: " + err.Error())
+		return
+	}
+
+	as.Digest = DigestState{Since: now, LastSent: now}
+}
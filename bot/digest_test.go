@@ -0,0 +1,66 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestDigestDue(t *testing.T) {
+	now := time.Date(2016, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		lastSent time.Time
+		hour     int
+		due      bool
+	}{
+		{time.Time{}, 8, true},                            // Never sent
+		{time.Time{}, 10, false},                          // Too early in the day
+		{now.Add(-time.Hour), 8, false},                   // Already sent today
+		{now.AddDate(0, 0, -1), 8, true},                  // Sent yesterday
+		{now.AddDate(0, 0, -1), 23, false},                // Sent yesterday, but too early
+		{now.Add(-9*time.Hour - 31*time.Minute), 0, true}, // Sent just before midnight
+	}
+
+	for i, c := range cases {
+		if digestDue(c.lastSent, c.hour, now) != c.due {
+			t.Error("Wrong digest due decision for case " + strconv.Itoa(i) + " (expected: " + strconv.FormatBool(c.due) + ")")
+		}
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	now := time.Date(2016, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	as := &AccountState{
+		LastFRR: 0.05,
+		Digest:  DigestState{Since: now.AddDate(0, 0, -1), OffersPlaced: 3, OffersCancelled: 2, LoansFilled: 1, AmountFilled: 0.5},
+		Interest: InterestPayments{
+			InterestPayment{Currency: "btc", Time: now.AddDate(0, 0, -2), Amount: 1}, // Before the digest period
+			InterestPayment{Currency: "btc", Time: now.Add(-time.Hour), Amount: 0.001},
+		},
+	}
+
+	balance := bitfinex.WalletBalances{
+		bitfinex.WalletKey{Type: "deposit", Currency: "btc"}:  bitfinex.WalletBalance{Amount: 2, Available: 0.1},
+		bitfinex.WalletKey{Type: "exchange", Currency: "usd"}: bitfinex.WalletBalance{Amount: 100},
+	}
+
+	digest := buildDigest("acc", as, balance, now)
+
+	for _, expected := range []string{"btc: 2 (available: 0.1)", "Offers placed: 3", "Offers cancelled: 2",
+		"Loans filled: 1 (0.5)", "btc: 0.00100000", "Current FRR: 0.05 %/day"} {
+		if !strings.Contains(digest, expected) {
+			t.Error("Digest is missing \"" + expected + "\":\n" + digest)
+		}
+	}
+
+	if strings.Contains(digest, "usd") {
+		t.Error("Digest contains non-deposit wallet:\n" + digest)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+// How far back to look for interest payments on the first sync
+const interestHistoryDays = 90
+
+// Ledger entries per history request
+const ledgerPageSize = 1000
+
+// InterestPayment is a (net of fees) interest payout to the deposit wallet
+type InterestPayment struct {
+	Currency string
+	Time     time.Time
+	Amount   float64
+	Balance  float64 // Wallet balance after the payment
+	USDPrice float64 // Price at the time of sync, 0 if unknown
+}
+
+// InterestPayments ...
+type InterestPayments []InterestPayment
+
+// EarningsRow ...
+type EarningsRow struct {
+	Account  string
+	Currency string
+	Date     string // "total" for the per-currency summary
+	Interest float64
+	Fees     float64
+	APR      float64 // Realized, in % / year
+	USD      float64
+
+	// Value in the reporting currency, if it is not USD
+	Value         float64 `json:",omitempty"`
+	ValueCurrency string  `json:",omitempty"`
+}
+
+// EarningsRows ...
+type EarningsRows []EarningsRow
+
+func isInterestPayment(description string) bool {
+	d := strings.ToLower(description)
+	return strings.Contains(d, "payment") && (strings.Contains(d, "swap") || strings.Contains(d, "funding") || strings.Contains(d, "interest"))
+}
+
+// UpdateInterest pulls new interest payments from the ledger into the local state
+func UpdateInterest(bconf config.Config, as *AccountState) (err error) {
+	if as.InterestSynced == nil {
+		as.InterestSynced = map[string]time.Time{}
+	}
+
+	// Interest may be paid for any currency we have lent out
+	currencies := []string{strings.ToLower(bconf.Bitfinex.ActiveWallet)}
+	for _, l := range as.Loans {
+		if !containsString(currencies, l.Currency) {
+			currencies = append(currencies, l.Currency)
+		}
+	}
+
+	for _, cur := range currencies {
+		since, ok := as.InterestSynced[cur]
+		if !ok {
+			since = time.Now().AddDate(0, 0, -interestHistoryDays)
+		}
+
+		entries, err := ledgerSince(bconf.ExtAPI, cur, since)
+		if err != nil {
+			return errors.New("Failed to get " + cur + " ledger: " + err.Error())
+		}
+
+		price := -1.0
+		for _, e := range entries {
+			t := time.Unix(int64(e.Timestamp), 0).UTC()
+			if !t.After(since) || !isInterestPayment(e.Description) {
+				continue
+			}
+
+			// Look up the price only once per currency
+			if price < 0 {
+				price, err = bconf.Price(cur, "usd")
+				if err != nil {
+					bconf.Logger().Println("\tWARNING: Failed to get " + cur + " USD price: " + err.Error())
+					price = 0
+				}
+			}
+
+			as.Interest = append(as.Interest, InterestPayment{Currency: cur, Time: t, Amount: e.Amount, Balance: e.Balance, USDPrice: price})
+
+			if t.After(as.InterestSynced[cur]) {
+				as.InterestSynced[cur] = t
+			}
+		}
+
+		if _, ok := as.InterestSynced[cur]; !ok {
+			as.InterestSynced[cur] = since
+		}
+	}
+
+	sort.Slice(as.Interest, func(i, j int) bool { return as.Interest[i].Time.Before(as.Interest[j].Time) })
+
+	return
+}
+
+// ledgerSince returns every ledger entry of the currency since the given time,
+// paging backwards until the exchange returns a short page
+func ledgerSince(api *exchange.BitfinexExt, cur string, since time.Time) (entries exchange.LedgerEntries, err error) {
+	var until time.Time
+	seen := map[exchange.LedgerEntry]bool{}
+
+	for {
+		page, err := api.History(cur, since, until, ledgerPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		oldest := until
+		for _, e := range page {
+			// The oldest second of a page is requested again, in case the page ended within it
+			if !seen[e] {
+				seen[e] = true
+				entries = append(entries, e)
+			}
+
+			t := time.Unix(int64(e.Timestamp), 0).UTC()
+			if oldest.IsZero() || t.Before(oldest) {
+				oldest = t
+			}
+		}
+
+		if len(page) < ledgerPageSize || !oldest.After(since) {
+			return entries, nil
+		}
+
+		// A full page within a single second, skip past it
+		if oldest.Equal(until) {
+			oldest = oldest.Add(-time.Second)
+		}
+		until = oldest
+	}
+}
+
+// EarningsReport aggregates interest payments in [since, until) into daily rows and a total per currency.
+// Fees are estimated from the exchange's cut of the gross interest (feePct).
+func EarningsReport(account string, payments InterestPayments, feePct float64, since, until time.Time) (rows EarningsRows) {
+	type dayKey struct{ currency, date string }
+
+	days := map[dayKey]*EarningsRow{}
+	bases := map[dayKey]float64{}
+	totals := map[string]*EarningsRow{}
+	totalBases := map[string]float64{}
+
+	for _, p := range payments {
+		if p.Time.Before(since) || !p.Time.Before(until) {
+			continue
+		}
+
+		fees := 0.0
+		if feePct > 0 && feePct < 100 {
+			fees = p.Amount/(1-feePct/100) - p.Amount
+		}
+
+		k := dayKey{p.Currency, p.Time.UTC().Format("2006-01-02")}
+		r, ok := days[k]
+		if !ok {
+			r = &EarningsRow{Account: account, Currency: p.Currency, Date: k.date}
+			days[k] = r
+		}
+
+		t, ok := totals[p.Currency]
+		if !ok {
+			t = &EarningsRow{Account: account, Currency: p.Currency, Date: "total"}
+			totals[p.Currency] = t
+		}
+
+		for _, row := range []*EarningsRow{r, t} {
+			row.Interest += p.Amount
+			row.Fees += fees
+			row.USD += p.Amount * p.USDPrice
+		}
+
+		// Interest is earned on the wallet balance before the payment
+		bases[k] += p.Balance - p.Amount
+	}
+
+	numDays := map[string]int{}
+	for k, r := range days {
+		if bases[k] > 0 {
+			r.APR = r.Interest / bases[k] * 365 * 100
+		}
+
+		numDays[k.currency]++
+		totalBases[k.currency] += bases[k]
+		rows = append(rows, *r)
+	}
+
+	for cur, t := range totals {
+		// Realized yield over the days with payments
+		if totalBases[cur] > 0 {
+			t.APR = t.Interest / (totalBases[cur] / float64(numDays[cur])) / float64(numDays[cur]) * 365 * 100
+		}
+
+		rows = append(rows, *t)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Currency != rows[j].Currency {
+			return rows[i].Currency < rows[j].Currency
+		}
+		// Keep totals last
+		if rows[i].Date == "total" || rows[j].Date == "total" {
+			return rows[j].Date == "total" && rows[i].Date != "total"
+		}
+		return rows[i].Date < rows[j].Date
+	})
+
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+func TestIsInterestPayment(t *testing.T) {
+	cases := map[string]bool{
+		"Swap Payment on wallet deposit":           true,
+		"Margin Funding Payment on wallet Deposit": true,
+		"Deposit (BITCOIN) #1234":                  false,
+		"Transfer of 1.0 BTC from wallet Exchange": false,
+	}
+
+	for d, expected := range cases {
+		if isInterestPayment(d) != expected {
+			t.Error("Wrong interest payment detection for \"" + d + "\" (expected: " + strconv.FormatBool(expected) + ")")
+		}
+	}
+}
+
+func TestEarningsReport(t *testing.T) {
+	day := time.Date(2016, 3, 1, 1, 0, 0, 0, time.UTC)
+
+	payments := InterestPayments{
+		// 0.01 % / day on 100 => 3.65 % / year
+		InterestPayment{Currency: "btc", Time: day, Amount: 0.01, Balance: 100.01, USDPrice: 400},
+		// 0.02 % / day on 100 => 7.3 % / year
+		InterestPayment{Currency: "btc", Time: day.AddDate(0, 0, 1), Amount: 0.02, Balance: 100.02, USDPrice: 400},
+		// Outside of the report range
+		InterestPayment{Currency: "btc", Time: day.AddDate(0, 0, 5), Amount: 1, Balance: 101, USDPrice: 400},
+	}
+
+	rows := EarningsReport("acc", payments, 20, day.Truncate(24*time.Hour), day.AddDate(0, 0, 2))
+
+	// Two days and a total
+	if len(rows) != 3 {
+		t.Fatal("Returned wrong number of rows (" + strconv.Itoa(len(rows)) + ", expected: 3)")
+	}
+
+	if rows[0].Date != "2016-03-01" || math.Abs(rows[0].APR-3.65) > 0.0000001 {
+		t.Errorf("Returned wrong first row %v (expected: 2016-03-01 @ 3.65 APR)", rows[0])
+	}
+
+	total := rows[2]
+	if total.Date != "total" {
+		t.Fatal("Returned wrong total row date (" + total.Date + ", expected: total)")
+	}
+
+	if math.Abs(total.Interest-0.03) > 0.0000001 || math.Abs(total.APR-5.475) > 0.0000001 || math.Abs(total.USD-12) > 0.0000001 {
+		t.Errorf("Returned wrong total row %v (expected: 0.03 interest @ 5.475 APR, 12 USD)", total)
+	}
+
+	// 20 % fee: net 0.03 => gross 0.0375
+	if math.Abs(total.Fees-0.0075) > 0.0000001 {
+		t.Error("Returned wrong fees (" + strconv.FormatFloat(total.Fees, 'f', -1, 64) + ", expected: 0.0075)")
+	}
+}
+
+func TestUpdateInterest_Paging(t *testing.T) {
+	since := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Three payments a second, more than two pages
+	var ledger exchange.LedgerEntries
+	for i := 0; i < 2*ledgerPageSize+10; i++ {
+		ts := float64(since.Unix() + 1 + int64(i/3))
+		ledger = append(ledger, exchange.LedgerEntry{Currency: "USD", Amount: 0.01, Balance: 100 + float64(i), Description: "Swap Payment on wallet deposit", Timestamp: ts})
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		payloadJSON, _ := base64.StdEncoding.DecodeString(r.Header.Get("X-BFX-PAYLOAD"))
+		payload := map[string]interface{}{}
+		json.Unmarshal(payloadJSON, &payload)
+
+		from, _ := strconv.ParseFloat(payload["since"].(string), 64)
+		until := math.Inf(1)
+		if u, ok := payload["until"].(string); ok {
+			until, _ = strconv.ParseFloat(u, 64)
+		}
+
+		// Newest first, both bounds inclusive
+		page := exchange.LedgerEntries{}
+		for i := len(ledger) - 1; i >= 0 && len(page) < int(payload["limit"].(float64)); i-- {
+			if ledger[i].Timestamp >= from && ledger[i].Timestamp <= until {
+				page = append(page, ledger[i])
+			}
+		}
+
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	defer func(url string) { exchange.APIURL = url }(exchange.APIURL)
+	exchange.APIURL = srv.URL + "/v1/"
+
+	conf := config.Config{Bitfinex: config.BitfinexConf{ActiveWallet: "usd"}, ExtAPI: exchange.NewBitfinexExt("key", "secret")}
+	as := &AccountState{InterestSynced: map[string]time.Time{"usd": since}}
+
+	if err := UpdateInterest(conf, as); err != nil {
+		t.Fatal("Failed to update interest: " + err.Error())
+	}
+
+	if len(as.Interest) != len(ledger) || requests < 3 {
+		t.Error("Interest payments lost (" + strconv.Itoa(len(as.Interest)) + " in " + strconv.Itoa(requests) + " requests, expected: " +
+			strconv.Itoa(len(ledger)) + ")")
+	}
+
+	newest := time.Unix(int64(ledger[len(ledger)-1].Timestamp), 0).UTC()
+	if !as.InterestSynced["usd"].Equal(newest) {
+		t.Error("Wrong sync time (" + as.InterestSynced["usd"].String() + ", expected: " + newest.String() + ")")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+// planDailyRate returns the amount weighted average daily rate of the plan's lends
+func planDailyRate(plan Plan) float64 {
+	amount, weighted := 0.0, 0.0
+	for _, a := range plan.Actions {
+		if a.Action == strategy.ActionLend {
+			amount += a.Amount.Float64()
+			weighted += a.Amount.Float64() * a.YearlyRate.Float64() / 365
+		}
+	}
+
+	if amount == 0 {
+		return 0
+	}
+
+	return weighted / amount
+}
+
+// checkGuardrails returns an error describing every limit the plan violates
+func checkGuardrails(g config.GuardrailsConf, plan Plan, lastDailyRate float64) error {
+	var violations []string
+
+	offers, amount := 0, exchange.Decimal{}
+	for _, a := range plan.Actions {
+		if a.Action != strategy.ActionLend {
+			continue
+		}
+
+		offers++
+		amount = amount.Add(a.Amount)
+
+		dailyRate := a.YearlyRate.Float64() / 365
+		if g.MinDailyRate > 0 && dailyRate < g.MinDailyRate {
+			violations = append(violations, "offer rate "+rateStr(dailyRate)+" below MinDailyRate "+rateStr(g.MinDailyRate))
+		}
+		if g.MaxDailyRate > 0 && dailyRate > g.MaxDailyRate {
+			violations = append(violations, "offer rate "+rateStr(dailyRate)+" above MaxDailyRate "+rateStr(g.MaxDailyRate))
+		}
+	}
+
+	if g.MaxOffers > 0 && offers > g.MaxOffers {
+		violations = append(violations, strconv.Itoa(offers)+" offers exceed MaxOffers "+strconv.Itoa(g.MaxOffers))
+	}
+
+	if g.MaxAmount > 0 && amount.Cmp(exchange.DecimalFromFloat(g.MaxAmount)) > 0 {
+		violations = append(violations, "offered amount "+amount.String()+" exceeds MaxAmount "+amountStr(g.MaxAmount))
+	}
+
+	if dailyRate := planDailyRate(plan); g.MaxRateChangePct > 0 && lastDailyRate > 0 && dailyRate > 0 {
+		change := math.Abs(dailyRate-lastDailyRate) / lastDailyRate * 100
+		if change > g.MaxRateChangePct {
+			violations = append(violations, "average offer rate changed "+strconv.FormatFloat(change, 'f', 1, 64)+"% ("+
+				rateStr(lastDailyRate)+" -> "+rateStr(dailyRate)+"), more than MaxRateChangePct "+amountStr(g.MaxRateChangePct)+"%")
+		}
+	}
+
+	if len(violations) > 0 {
+		return errors.New("Blocked by guardrails: " + strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// guardPlan checks the plan against the account's guardrails and raises an alert if it is blocked
+func guardPlan(conf config.Config, as *AccountState, plan Plan) error {
+	err := checkGuardrails(conf.Guardrails, plan, as.LastDailyRate)
+	if err != nil {
+		conf.Logger().Println("\tCRITICAL: " + err.Error())
+		sendEvent(conf, as, notify.Event{Type: notify.EventGuardrail, Account: conf.AccountName(), Currency: plan.Currency,
+			Severity: notify.SeverityCritical, Message: err.Error()})
+	}
+
+	return err
+}
+
+// planExecuted remembers the average rate of an executed plan for the rate change limit
+func (as *AccountState) planExecuted(plan Plan, dryRun bool) {
+	if dailyRate := planDailyRate(plan); !dryRun && dailyRate > 0 {
+		as.LastDailyRate = dailyRate
+	}
+}
+
+// killSwitchActive returns true if the kill switch file exists
+func (b *Bot) killSwitchActive() bool {
+	if b.KillSwitch == "" {
+		return false
+	}
+
+	_, err := os.Stat(b.KillSwitch)
+	return err == nil
+}
+
+func rateStr(dailyRate float64) string {
+	return strconv.FormatFloat(dailyRate, 'g', 10, 64) + " %/day"
+}
+
+func amountStr(amount float64) string {
+	return strconv.FormatFloat(amount, 'g', 12, 64)
+}
@@ -0,0 +1,106 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+func TestGuardrails(t *testing.T) {
+	plan := Plan{Actions: strategy.PlanActions{
+		strategy.PlanAction{Action: strategy.ActionCancelAll},
+		strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("100"), YearlyRate: exchange.MustDecimal("36.5"), Period: 2},
+		strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("300"), YearlyRate: exchange.MustDecimal("109.5"), Period: 30},
+	}}
+
+	if err := checkGuardrails(config.GuardrailsConf{}, plan, 0.01); err != nil {
+		t.Error("Disabled guardrails blocked the plan: " + err.Error())
+	}
+
+	// Average rate is 0.25 %/day
+	if r := planDailyRate(plan); r < 0.2499 || r > 0.2501 {
+		t.Error("Returned wrong average plan rate")
+	}
+
+	tests := []struct {
+		conf      config.GuardrailsConf
+		last      float64
+		violation string
+	}{
+		{config.GuardrailsConf{MinDailyRate: 0.2}, 0, "below MinDailyRate"},
+		{config.GuardrailsConf{MaxDailyRate: 0.2}, 0, "above MaxDailyRate"},
+		{config.GuardrailsConf{MaxOffers: 1}, 0, "exceed MaxOffers"},
+		{config.GuardrailsConf{MaxAmount: 399}, 0, "exceeds MaxAmount"},
+		{config.GuardrailsConf{MaxRateChangePct: 20}, 0.2, "more than MaxRateChangePct"},
+		{config.GuardrailsConf{MaxRateChangePct: 30}, 0.2, ""},
+		{config.GuardrailsConf{MaxRateChangePct: 20}, 0, ""}, // No previous run
+		{config.GuardrailsConf{MinDailyRate: 0.1, MaxDailyRate: 0.3, MaxOffers: 2, MaxAmount: 400}, 0, ""},
+	}
+
+	for _, test := range tests {
+		err := checkGuardrails(test.conf, plan, test.last)
+		if test.violation == "" && err != nil {
+			t.Errorf("Guardrails %+v blocked the plan: %v", test.conf, err)
+		}
+		if test.violation != "" && (err == nil || !strings.Contains(err.Error(), test.violation)) {
+			t.Errorf("Guardrails %+v did not report \"%s\": %v", test.conf, test.violation, err)
+		}
+	}
+}
+
+func TestGuardPlan_Alert(t *testing.T) {
+	sink, events := webhookSink()
+	defer sink.Close()
+
+	conf := config.Config{Name: "acc1", Guardrails: config.GuardrailsConf{MaxOffers: 1},
+		Notifications: notify.NotificationsConf{Webhooks: []notify.WebhookConf{{URL: sink.URL}}}}
+	as := &AccountState{}
+
+	plan := Plan{Currency: "usd", Actions: strategy.PlanActions{strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("1")}, strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("1")}}}
+	if err := guardPlan(conf, as, plan); err == nil {
+		t.Fatal("Plan not blocked")
+	}
+
+	if len(*events) != 1 || (*events)[0].Type != notify.EventGuardrail || (*events)[0].Severity != notify.SeverityCritical {
+		t.Errorf("Wrong alert sent: %+v", *events)
+	}
+
+	as.planExecuted(plan, true)
+	if as.LastDailyRate != 0 {
+		t.Error("Dry run changed the last executed rate")
+	}
+}
+
+func TestKillSwitch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blb")
+	if err != nil {
+		t.Fatal("Failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	b := &Bot{KillSwitch: filepath.Join(dir, "blb.halt")}
+
+	if b.killSwitchActive() {
+		t.Error("Kill switch active without file")
+	}
+
+	ioutil.WriteFile(b.KillSwitch, nil, 0600)
+	if !b.killSwitchActive() {
+		t.Fatal("Kill switch not active with file")
+	}
+
+	// Nothing may reach the exchange, API is nil
+	plan := Plan{Currency: "usd", Actions: strategy.PlanActions{strategy.PlanAction{Action: strategy.ActionCancelAll}}}
+	if err := b.executePlan(config.Config{}, plan, &RunStats{}, false); err == nil || !strings.Contains(err.Error(), "Kill switch") {
+		t.Errorf("Plan executed with kill switch: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Local HTTP JSON control API:
+//
+//	GET  /api/accounts                  accounts with balances, active offers and last run result
+//	GET  /api/accounts/{name}           single account
+//	GET  /api/accounts/{name}/plan      plan the strategy would execute right now (?explain=true for derivations)
+//	GET  /api/dashboard                 balances, history, offer ladder and run logs for the dashboard
+//	POST /api/accounts/{name}/run       run now (?dryrun=true for a dry run)
+//	POST /api/accounts/{name}/pause     pause lending for the account
+//	POST /api/accounts/{name}/resume    resume lending for the account
+//	POST /api/currencies/{cur}/pause    pause lending in the currency for all accounts
+//	POST /api/currencies/{cur}/resume   resume lending in the currency for all accounts
+
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Balance ...
+type Balance struct {
+	Type      string
+	Currency  string
+	Amount    float64
+	Available float64
+}
+
+// AccountStatus ...
+type AccountStatus struct {
+	Name     string
+	Strategy string
+	Currency string
+	Paused   bool
+	Balances []Balance
+	Offers   bitfinex.Offers
+	LastRun  *RunResult
+	Error    string `json:",omitempty"`
+}
+
+type apiHandler struct {
+	bot   *Bot
+	token string
+}
+
+func newAPIHandler(b *Bot, token string) http.Handler {
+	return &apiHandler{bot: b, token: token}
+}
+
+func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+h.token)) != 1 {
+		writeJSONError(w, http.StatusUnauthorized, errors.New("Invalid or missing bearer token"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+
+	h.bot.Lock()
+	defer h.bot.Unlock()
+
+	switch {
+	case len(parts) == 1 && parts[0] == "accounts" && r.Method == "GET":
+		var all []AccountStatus
+		for _, conf := range h.bot.Confs {
+			all = append(all, h.bot.Status(conf))
+		}
+		writeJSON(w, http.StatusOK, all)
+
+	case len(parts) == 1 && parts[0] == "dashboard" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, h.bot.dashboard())
+
+	case len(parts) >= 2 && parts[0] == "accounts":
+		conf, ok := h.bot.account(parts[1])
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, errors.New("Unknown account: "+parts[1]))
+			return
+		}
+
+		h.serveAccount(w, r, conf, parts[2:])
+
+	case len(parts) == 3 && parts[0] == "currencies" && r.Method == "POST":
+		cur := strings.ToLower(parts[1])
+		switch parts[2] {
+		case "pause":
+			if !containsString(h.bot.State.PausedCurrencies, cur) {
+				h.bot.State.PausedCurrencies = append(h.bot.State.PausedCurrencies, cur)
+			}
+		case "resume":
+			var paused []string
+			for _, c := range h.bot.State.PausedCurrencies {
+				if c != cur {
+					paused = append(paused, c)
+				}
+			}
+			h.bot.State.PausedCurrencies = paused
+		default:
+			writeJSONError(w, http.StatusNotFound, errors.New("Unknown action: "+parts[2]))
+			return
+		}
+
+		h.saveAndReply(w, map[string]interface{}{"PausedCurrencies": h.bot.State.PausedCurrencies})
+
+	default:
+		writeJSONError(w, http.StatusNotFound, errors.New("Not found"))
+	}
+}
+
+func (h *apiHandler) serveAccount(w http.ResponseWriter, r *http.Request, conf config.Config, action []string) {
+	as := h.bot.State.Account(conf.AccountName())
+
+	switch {
+	case len(action) == 0 && r.Method == "GET":
+		writeJSON(w, http.StatusOK, h.bot.Status(conf))
+
+	case len(action) == 1 && action[0] == "plan" && r.Method == "GET":
+		conf.Explain, _ = strconv.ParseBool(r.URL.Query().Get("explain"))
+
+		plan, err := PlanStrategy(conf)
+		if err != nil {
+			writeJSONError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, plan)
+
+	case len(action) == 1 && action[0] == "run" && r.Method == "POST":
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryrun"))
+
+		err := h.bot.RunOnce(conf.AccountName(), true, dryRun)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, as.LastRun)
+
+	case len(action) == 1 && (action[0] == "pause" || action[0] == "resume") && r.Method == "POST":
+		as.Paused = action[0] == "pause"
+		h.saveAndReply(w, map[string]interface{}{"Name": conf.AccountName(), "Paused": as.Paused})
+
+	default:
+		writeJSONError(w, http.StatusNotFound, errors.New("Not found"))
+	}
+}
+
+func (h *apiHandler) saveAndReply(w http.ResponseWriter, v interface{}) {
+	err := h.bot.SaveState()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, errors.New("Failed to save state: "+err.Error()))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+// Status collects live balances and offers of the account together with its local state
+func (b *Bot) Status(conf config.Config) (s AccountStatus) {
+	as := b.State.Account(conf.AccountName())
+
+	s = AccountStatus{
+		Name:     conf.AccountName(),
+		Strategy: conf.Strategy.Active,
+		Currency: strings.ToLower(conf.Bitfinex.ActiveWallet),
+		Paused:   b.State.paused(conf),
+		LastRun:  as.LastRun,
+	}
+
+	balance, err := conf.API.WalletBalances()
+	if err != nil {
+		s.Error = "Failed to get wallet funds: " + err.Error()
+		return
+	}
+	s.Balances = BalancesList(balance)
+
+	offers, err := conf.API.ActiveOffers()
+	if err != nil {
+		s.Error = "Failed to get active offers: " + err.Error()
+		return
+	}
+
+	for _, o := range offers {
+		if strings.ToLower(o.Direction) == bitfinex.LEND {
+			s.Offers = append(s.Offers, o)
+		}
+	}
+
+	return
+}
+
+// BalancesList returns the wallet balances sorted by wallet and currency
+func BalancesList(balance bitfinex.WalletBalances) (list []Balance) {
+	for k, v := range balance {
+		list = append(list, Balance{Type: k.Type, Currency: k.Currency, Amount: v.Amount, Available: v.Available})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Type != list[j].Type {
+			return list[i].Type < list[j].Type
+		}
+		return list[i].Currency < list[j].Currency
+	})
+
+	return
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"Error": err.Error()})
+}
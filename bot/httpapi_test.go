@@ -0,0 +1,114 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+)
+
+func testBot(t *testing.T) (b *Bot, cleanup func()) {
+	dir, err := ioutil.TempDir("", "blb")
+	if err != nil {
+		t.Fatal("Failed to create temp dir: " + err.Error())
+	}
+
+	b = &Bot{
+		Confs: config.Configs{
+			config.Config{Name: "acc1", Bitfinex: config.BitfinexConf{ActiveWallet: "BTC"}},
+			config.Config{Name: "acc2", Bitfinex: config.BitfinexConf{ActiveWallet: "usd"}},
+		},
+		State:     &State{Accounts: map[string]*AccountState{}},
+		StateFile: filepath.Join(dir, "blb.state"),
+	}
+
+	return b, func() { os.RemoveAll(dir) }
+}
+
+func apiRequest(h http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestAPI_Auth(t *testing.T) {
+	b, cleanup := testBot(t)
+	defer cleanup()
+
+	h := newAPIHandler(b, "secret")
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := apiRequest(h, "POST", "/api/accounts/acc1/pause", token); rec.Code != http.StatusUnauthorized {
+			t.Error("Returned wrong status for token \"" + token + "\" (" + strconv.Itoa(rec.Code) + ", expected: 401)")
+		}
+	}
+
+	if b.State.Account("acc1").Paused {
+		t.Error("Unauthorized request paused the account")
+	}
+
+	// API without a token configured is always closed
+	if rec := apiRequest(newAPIHandler(b, ""), "POST", "/api/accounts/acc1/pause", ""); rec.Code != http.StatusUnauthorized {
+		t.Error("Returned wrong status without configured token (" + strconv.Itoa(rec.Code) + ", expected: 401)")
+	}
+}
+
+func TestAPI_PauseResume(t *testing.T) {
+	b, cleanup := testBot(t)
+	defer cleanup()
+
+	h := newAPIHandler(b, "secret")
+
+	if rec := apiRequest(h, "POST", "/api/accounts/acc1/pause", "secret"); rec.Code != http.StatusOK {
+		t.Fatal("Returned wrong status (" + strconv.Itoa(rec.Code) + ", expected: 200): " + rec.Body.String())
+	}
+
+	if !b.State.paused(b.Confs[0]) || b.State.paused(b.Confs[1]) {
+		t.Error("Wrong accounts paused after account pause")
+	}
+
+	// State is persisted
+	st, err := loadState(b.StateFile)
+	if err != nil || !st.Account("acc1").Paused {
+		t.Error("Pause was not saved to the state file")
+	}
+
+	apiRequest(h, "POST", "/api/accounts/acc1/resume", "secret")
+	apiRequest(h, "POST", "/api/currencies/USD/pause", "secret")
+
+	if b.State.paused(b.Confs[0]) || !b.State.paused(b.Confs[1]) {
+		t.Error("Wrong accounts paused after currency pause")
+	}
+
+	apiRequest(h, "POST", "/api/currencies/usd/resume", "secret")
+
+	if b.State.paused(b.Confs[1]) {
+		t.Error("Currency still paused after resume")
+	}
+}
+
+func TestAPI_NotFound(t *testing.T) {
+	b, cleanup := testBot(t)
+	defer cleanup()
+
+	h := newAPIHandler(b, "secret")
+
+	for _, path := range []string{"/api/accounts/nope", "/api/accounts/acc1/unknown", "/api/unknown"} {
+		if rec := apiRequest(h, "GET", path, "secret"); rec.Code != http.StatusNotFound {
+			t.Error("Returned wrong status for " + path + " (" + strconv.Itoa(rec.Code) + ", expected: 404)")
+		}
+	}
+}
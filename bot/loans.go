@@ -0,0 +1,101 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+// Loan is an active credit (funds currently lent out)
+type Loan struct {
+	ID       int
+	Currency string
+	Amount   float64
+	Rate     float64 // Yearly
+	Period   int
+	Start    time.Time
+	Expiry   time.Time
+}
+
+// Loans ...
+type Loans []Loan
+
+// MaturityEntry is the amount returning to the wallet on a given day
+type MaturityEntry struct {
+	Date       string
+	Amount     float64
+	NumLoans   int
+	YearlyRate float64 // Amount weighted
+}
+
+// MaturitySchedule is a per-currency list of maturities sorted by date
+type MaturitySchedule map[string][]MaturityEntry
+
+func loansFromCredits(credits exchange.Credits) (loans Loans) {
+	for _, c := range credits {
+		start := time.Unix(int64(c.Timestamp), 0).UTC()
+
+		loans = append(loans, Loan{
+			ID:       c.ID,
+			Currency: strings.ToLower(c.Currency),
+			Amount:   c.Amount,
+			Rate:     c.Rate,
+			Period:   c.Period,
+			Start:    start,
+			Expiry:   start.AddDate(0, 0, c.Period),
+		})
+	}
+
+	sort.Slice(loans, func(i, j int) bool { return loans[i].Expiry.Before(loans[j].Expiry) })
+
+	return
+}
+
+// UpdateLoans refreshes the active loans of the account in the local state
+func UpdateLoans(bconf config.Config, as *AccountState) (err error) {
+	credits, err := bconf.ExtAPI.ActiveCredits()
+	if err != nil {
+		return
+	}
+
+	as.Loans = loansFromCredits(credits)
+	as.LoansUpdated = time.Now().UTC()
+
+	return
+}
+
+// LoanMaturitySchedule sums the loans of every currency by expiry date
+func LoanMaturitySchedule(loans Loans) (schedule MaturitySchedule) {
+	schedule = MaturitySchedule{}
+
+	index := map[string]int{}
+	for _, l := range loans {
+		date := l.Expiry.UTC().Format("2006-01-02")
+		key := l.Currency + " " + date
+
+		i, ok := index[key]
+		if !ok {
+			schedule[l.Currency] = append(schedule[l.Currency], MaturityEntry{Date: date})
+			i = len(schedule[l.Currency]) - 1
+			index[key] = i
+		}
+
+		e := &schedule[l.Currency][i]
+		if e.Amount+l.Amount > 0 {
+			e.YearlyRate = (e.YearlyRate*e.Amount + l.Rate*l.Amount) / (e.Amount + l.Amount)
+		}
+		e.Amount += l.Amount
+		e.NumLoans++
+	}
+
+	for _, entries := range schedule {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Date < entries[j].Date })
+	}
+
+	return
+}
@@ -0,0 +1,65 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+func TestLoansFromCredits(t *testing.T) {
+	start := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	credits := exchange.Credits{
+		exchange.Credit{ID: 1, Currency: "BTC", Rate: 36.5, Period: 30, Amount: 1, Timestamp: float64(start.Unix())},
+		exchange.Credit{ID: 2, Currency: "USD", Rate: 73, Period: 2, Amount: 100, Timestamp: float64(start.Unix())},
+	}
+
+	loans := loansFromCredits(credits)
+
+	if len(loans) != 2 {
+		t.Fatal("Returned wrong number of loans (" + strconv.Itoa(len(loans)) + ", expected: 2)")
+	}
+
+	// Sorted by expiry: 2 day loan first
+	if loans[0].ID != 2 || loans[0].Currency != "usd" {
+		t.Error("Returned wrong first loan (ID " + strconv.Itoa(loans[0].ID) + " " + loans[0].Currency + ", expected: ID 2 usd)")
+	}
+
+	if !loans[1].Expiry.Equal(start.AddDate(0, 0, 30)) {
+		t.Error("Returned wrong expiry (" + loans[1].Expiry.String() + ", expected: " + start.AddDate(0, 0, 30).String() + ")")
+	}
+}
+
+func TestLoanMaturitySchedule(t *testing.T) {
+	day := time.Date(2016, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	loans := Loans{
+		Loan{Currency: "btc", Amount: 1, Rate: 10, Expiry: day.Add(5 * time.Hour)},
+		Loan{Currency: "btc", Amount: 3, Rate: 20, Expiry: day.Add(10 * time.Hour)},
+		Loan{Currency: "btc", Amount: 2, Rate: 30, Expiry: day.AddDate(0, 0, -1)},
+		Loan{Currency: "usd", Amount: 50, Rate: 40, Expiry: day},
+	}
+
+	schedule := LoanMaturitySchedule(loans)
+
+	if len(schedule["btc"]) != 2 || len(schedule["usd"]) != 1 {
+		t.Fatal("Returned wrong number of maturity dates (" + strconv.Itoa(len(schedule["btc"])) + " btc, " +
+			strconv.Itoa(len(schedule["usd"])) + " usd, expected: 2 btc, 1 usd)")
+	}
+
+	// Earliest date first
+	if schedule["btc"][0].Date != "2016-01-02" {
+		t.Error("Returned wrong first maturity date (" + schedule["btc"][0].Date + ", expected: 2016-01-02)")
+	}
+
+	// Two loans aggregated on the same day with amount weighted rate
+	e := schedule["btc"][1]
+	if e.NumLoans != 2 || math.Abs(e.Amount-4) > 0.0000000001 || math.Abs(e.YearlyRate-17.5) > 0.0000000001 {
+		t.Errorf("Returned wrong maturity entry %v (expected: 2 loans, 4 amount, 17.5 APR)", e)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// accountMarket is the market with the account's upcoming liquidity needs,
+// which the bot plans with after the strategy
+type accountMarket struct {
+	strategy.Market
+
+	Needs []liquidityNeed // Upcoming liquidity needs
+	Loans Loans           // Active loans in the currency, only with needs
+}
+
+// SnapshotOffer ...
+type SnapshotOffer struct {
+	ID     int
+	Rate   float64 // %/year
+	Period int
+	Amount float64 // Remaining
+}
+
+// PlanSnapshot records the market data a plan was based on
+type PlanSnapshot struct {
+	Time            time.Time
+	WalletAmount    float64
+	WalletAvailable float64
+	MinLoan         float64
+	Offers          []SnapshotOffer
+	Lendbook        []exchange.DepthLevel `json:",omitempty"`
+
+	// SHA-256 of the snapshot data, excluding Time
+	Hash string
+}
+
+// fetchMarket gets the market data of the account's active currency. A lendbook already
+// fetched during the run is used instead of getting the book again.
+func fetchMarket(bconf config.Config, lendbook *bitfinex.Lendbook) (m accountMarket, err error) {
+	api := bconf.API
+	logger := bconf.Logger()
+	m.Currency = strings.ToLower(bconf.Bitfinex.ActiveWallet)
+
+	logger.Println("\tGetting active lend offers...")
+	m.Offers, err = exchange.ActiveLendOffers(api, m.Currency)
+	if err != nil {
+		return
+	}
+
+	if lendbook != nil {
+		m.Lendbook = *lendbook
+	} else {
+		logger.Println("\tGetting current lendbook...")
+
+		m.Lendbook, err = api.Lendbook(m.Currency, 0, 10000)
+		if err != nil {
+			return
+		}
+	}
+
+	err = exchange.ValidateLendbook(m.Lendbook)
+	if err != nil {
+		return
+	}
+
+	logger.Println("\tGetting current wallet balance...")
+	balance, err := api.WalletBalances()
+	if err != nil {
+		return m, errors.New("Failed to get wallet funds: " + err.Error())
+	}
+
+	m.Wallet = balance[bitfinex.WalletKey{"deposit", m.Currency}]
+
+	m.Info = bconf.CurrencyInfo(m.Currency)
+
+	// The minimum offer size comes from the exchange's funding limits or the config,
+	// MinLoanUSD is only a fallback for USD
+	m.MinLoan = m.Info.MinOfferSize
+	if m.MinLoan <= 0 && m.Currency == "usd" {
+		m.MinLoan = bconf.Bitfinex.MinLoanUSD
+	}
+	if m.MinLoan <= 0 {
+		return m, errors.New("No minimum offer size known for " + m.Currency + ", set Bitfinex.Currencies." + m.Currency + ".MinOfferSize")
+	}
+
+	rc := bconf.Reserve(m.Currency)
+	m.Reserve = reserveAmount(rc, m.Wallet.Amount)
+
+	m.Needs, err = upcomingNeeds(rc, time.Now().UTC())
+	if err != nil {
+		return
+	}
+
+	if len(m.Needs) > 0 {
+		logger.Println("\tGetting active loans...")
+
+		credits, err := bconf.ExtAPI.ActiveCredits()
+		if err != nil {
+			return m, errors.New("Failed to get active loans: " + err.Error())
+		}
+
+		for _, l := range loansFromCredits(credits) {
+			if l.Currency == m.Currency {
+				m.Loans = append(m.Loans, l)
+			}
+		}
+	}
+
+	// Sanity check: is there anything to lend?
+	if m.Wallet.Amount < m.MinLoan {
+		logger.Println("\tWARNING: Wallet amount (" +
+			strconv.FormatFloat(m.Wallet.Amount, 'f', -1, 64) + " " + m.Currency + ") is less than the allowed minimum (" +
+			strconv.FormatFloat(m.MinLoan, 'f', -1, 64) + " " + m.Currency + ")")
+	}
+
+	return
+}
+
+// marketSnapshot records the market data for the plan
+func marketSnapshot(m strategy.Market, now time.Time) *PlanSnapshot {
+	s := &PlanSnapshot{
+		WalletAmount:    m.Wallet.Amount,
+		WalletAvailable: m.Wallet.Available,
+		MinLoan:         m.MinLoan,
+	}
+
+	for _, o := range m.Offers {
+		s.Offers = append(s.Offers, SnapshotOffer{ID: o.ID, Rate: o.Rate, Period: o.Period, Amount: o.RemainingAmount})
+	}
+
+	for _, o := range m.Lendbook.Asks {
+		s.Lendbook = append(s.Lendbook, exchange.DepthLevel{Rate: o.Rate, Amount: o.Amount, FRR: o.FRR})
+	}
+
+	// Hash before the time is set, so that identical market data hashes the same
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	s.Hash = hex.EncodeToString(sum[:])
+	s.Time = now
+
+	return s
+}
@@ -0,0 +1,114 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+)
+
+// sendEvent sends the event to all interested sinks, skipping recently sent duplicates
+func sendEvent(conf config.Config, as *AccountState, e notify.Event) {
+	sendEventTo(conf, as, e, nil)
+}
+
+// sendEventTo is sendEvent limited to the sinks accepted by route (all if nil)
+func sendEventTo(conf config.Config, as *AccountState, e notify.Event, route func(sink string) bool) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Key == "" {
+		e.Key = e.Type
+	}
+
+	if as.Notified == nil {
+		as.Notified = map[string]time.Time{}
+	}
+
+	dedup := e.Dedup
+	if dedup <= 0 {
+		dedup = time.Duration(conf.Notifications.DedupMinutes * float64(time.Minute))
+	}
+	if dedup <= 0 {
+		dedup = notify.DefaultDedupMinutes * time.Minute
+	}
+
+	for _, n := range conf.Notifications.Notifiers() {
+		if !n.Accepts(e) || (route != nil && !route(n.ID())) {
+			continue
+		}
+
+		key := n.ID() + " " + e.Key
+		if last, ok := as.Notified[key]; ok && e.Time.Sub(last) < dedup {
+			continue
+		}
+
+		err := n.Send(e)
+		if err != nil {
+			conf.Logger().Println("\tWARNING: Failed to send " + e.Type + " notification to " + n.ID() + ": " + err.Error())
+			continue
+		}
+
+		as.Notified[key] = e.Time
+	}
+}
+
+func newLoanEvents(account string, prev, cur Loans) (events []notify.Event) {
+	known := map[int]bool{}
+	for _, l := range prev {
+		known[l.ID] = true
+	}
+
+	for _, l := range cur {
+		if known[l.ID] {
+			continue
+		}
+
+		events = append(events, notify.Event{
+			Type:     notify.EventLoanFilled,
+			Account:  account,
+			Currency: l.Currency,
+			Message: "Loan filled: " + strconv.FormatFloat(l.Amount, 'f', -1, 64) + " " + l.Currency + " @ " +
+				strconv.FormatFloat(l.Rate/365, 'f', -1, 64) + " %/day for " + strconv.Itoa(l.Period) + " days",
+			Value: l.Amount,
+			Key:   notify.EventLoanFilled + " " + strconv.Itoa(l.ID),
+		})
+	}
+
+	return
+}
+
+// frrCrossEvents reports every configured daily FRR level crossed between the last and the current run
+func frrCrossEvents(account, currency string, levels []float64, lastFRR, dailyFRR float64) (events []notify.Event) {
+	if lastFRR <= 0 || dailyFRR <= 0 {
+		return
+	}
+
+	for _, level := range levels {
+		direction := ""
+		if lastFRR < level && dailyFRR >= level {
+			direction = "above"
+		} else if lastFRR >= level && dailyFRR < level {
+			direction = "below"
+		}
+
+		if direction == "" {
+			continue
+		}
+
+		events = append(events, notify.Event{
+			Type:     notify.EventFRRCross,
+			Account:  account,
+			Currency: currency,
+			Message: currency + " FRR moved " + direction + " " + strconv.FormatFloat(level, 'f', -1, 64) +
+				" %/day (now " + strconv.FormatFloat(dailyFRR, 'f', -1, 64) + " %/day)",
+			Value: dailyFRR,
+			Key:   notify.EventFRRCross + " " + strconv.FormatFloat(level, 'f', -1, 64) + " " + direction,
+		})
+	}
+
+	return
+}
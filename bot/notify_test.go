@@ -0,0 +1,98 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+)
+
+func TestNewLoanEvents(t *testing.T) {
+	prev := Loans{Loan{ID: 1}}
+	cur := Loans{Loan{ID: 1}, Loan{ID: 2, Currency: "btc", Amount: 1, Rate: 36.5, Period: 2}}
+
+	events := newLoanEvents("acc", prev, cur)
+
+	if len(events) != 1 {
+		t.Fatal("Returned wrong number of events (" + strconv.Itoa(len(events)) + ", expected: 1)")
+	}
+
+	if events[0].Type != notify.EventLoanFilled || events[0].Key != "loan_filled 2" {
+		t.Errorf("Returned wrong event %v (expected: loan_filled for loan 2)", events[0])
+	}
+}
+
+func TestFRRCrossEvents(t *testing.T) {
+	levels := []float64{0.05, 0.1}
+
+	// Crossing 0.05 upwards only
+	events := frrCrossEvents("acc", "btc", levels, 0.04, 0.06)
+	if len(events) != 1 || events[0].Key != "frr_cross 0.05 above" {
+		t.Errorf("Returned wrong events %v (expected: one crossing above 0.05)", events)
+	}
+
+	// Crossing both levels downwards
+	events = frrCrossEvents("acc", "btc", levels, 0.2, 0.01)
+	if len(events) != 2 {
+		t.Errorf("Returned wrong events %v (expected: two crossings below)", events)
+	}
+
+	// First run has nothing to compare against
+	events = frrCrossEvents("acc", "btc", levels, 0, 0.2)
+	if len(events) != 0 {
+		t.Errorf("Returned wrong events %v (expected: none)", events)
+	}
+}
+
+func TestNotify_WebhookRetryTemplateAndDedup(t *testing.T) {
+	defer func(d time.Duration) { notify.WebhookRetryDelay = d }(notify.WebhookRetryDelay)
+	notify.WebhookRetryDelay = time.Millisecond
+
+	requests := 0
+	lastBody := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		// Fail the first attempt
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		lastBody = string(body)
+	}))
+	defer srv.Close()
+
+	conf := config.Config{Notifications: notify.NotificationsConf{
+		Webhooks: []notify.WebhookConf{{URL: srv.URL, Retries: 1, Template: `{"text": {{json .Message}}}`, Events: []string{notify.EventRunFailed}}},
+	}}
+	as := &AccountState{}
+
+	sendEvent(conf, as, notify.Event{Type: notify.EventRunFailed, Message: `Failed "badly"`})
+
+	if requests != 2 {
+		t.Error("Made wrong number of requests (" + strconv.Itoa(requests) + ", expected: 2)")
+	}
+
+	if lastBody != `{"text": "Failed \"badly\""}` {
+		t.Error("Sent wrong body (" + lastBody + ")")
+	}
+
+	// Duplicate within the dedup interval is not sent
+	sendEvent(conf, as, notify.Event{Type: notify.EventRunFailed, Message: "Failed again"})
+
+	// Event type not subscribed to is not sent
+	sendEvent(conf, as, notify.Event{Type: notify.EventLoanFilled, Message: "Filled"})
+
+	if requests != 2 {
+		t.Error("Made wrong number of requests (" + strconv.Itoa(requests) + ", expected: 2)")
+	}
+}
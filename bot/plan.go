@@ -0,0 +1,201 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Plan is the set of exchange actions a strategy wants to take, in execution order
+type Plan struct {
+	Account  string
+	Strategy string
+	Schedule []string `json:",omitempty"` // Active schedule rules
+	Currency string
+	Created  time.Time
+	Snapshot *PlanSnapshot `json:",omitempty"`
+	Actions  strategy.PlanActions
+
+	// Offer records as the strategy reconciled them, kept only once the plan is executed
+	owned *strategy.OwnedOffers
+}
+
+// brief returns the plan without the lendbook snapshot, for keeping in the local state
+func (p Plan) brief() Plan {
+	if p.Snapshot != nil {
+		s := *p.Snapshot
+		s.Lendbook = nil
+		p.Snapshot = &s
+	}
+
+	return p
+}
+
+// executePlan takes the plan's actions on the exchange, counting them in stats
+func (b *Bot) executePlan(bconf config.Config, plan Plan, stats *RunStats, dryRun bool) (err error) {
+	api := bconf.API
+	activeWallet := plan.Currency
+	info := bconf.CurrencyInfo(activeWallet)
+	logger := bconf.Logger()
+
+	if !dryRun && b.killSwitchActive() {
+		return errors.New("Kill switch " + b.KillSwitch + " present, not executing plan")
+	}
+
+	if !dryRun && plan.owned != nil && bconf.Owned != nil {
+		*bconf.Owned = *plan.owned
+	}
+
+	for _, a := range plan.Actions {
+		switch a.Action {
+		case strategy.ActionCancelAll:
+			// Never cancel by currency, that would take manual offers too
+			return errors.New("Plan cancels all offers, only cancels of the bot's own offers by ID are executed")
+		case strategy.ActionCancel:
+			logger.Println("\tCanceling offer ID: " + strconv.Itoa(a.OfferID))
+			a.Explain.Log(logger)
+
+			if !dryRun {
+				err = api.CancelOffer(a.OfferID)
+				if err != nil {
+					return errors.New("Failed to cancel offer: " + err.Error())
+				}
+
+				stats.offersCancelled(1)
+				bconf.Owned.Cancelled(a.OfferID)
+			}
+		case strategy.ActionLend:
+			// Plans may come from a file, never submit more precision than the currency allows
+			amount := a.Amount.Round(info.AmountPlaces, exchange.RoundDown)
+			rate := a.YearlyRate.Round(exchange.RatePlaces, exchange.RoundUp)
+
+			logger.Println("\tPlacing offer: " +
+				amount.String() + " " + activeWallet + " @ " +
+				strconv.FormatFloat(rate.Float64()/365, 'f', -1, 64) + " %/day for " + strconv.Itoa(a.Period) + " days")
+			a.Explain.Log(logger)
+
+			if !dryRun {
+				offer, err := api.NewOffer(strings.ToUpper(activeWallet), amount.Float64(), rate.Float64(), a.Period, bitfinex.LEND)
+				if err != nil {
+					return errors.New("Failed to place new offer: " + err.Error())
+				}
+
+				stats.offerPlaced()
+				owner := a.Strategy
+				if owner == "" {
+					owner = plan.Strategy
+				}
+				bconf.Owned.Placed(owner, offer.ID, amount.Float64())
+			}
+		default:
+			return errors.New("Unknown plan action: " + a.Action)
+		}
+	}
+
+	logger.Println("\tRun done.")
+
+	return
+}
+
+// PlanStrategy asks the active strategy what it would do right now, without touching any offers
+func PlanStrategy(conf config.Config) (plan Plan, err error) {
+	return planStrategy(conf, nil)
+}
+
+// planStrategy plans with the lendbook already fetched during the run, if not nil
+func planStrategy(conf config.Config, lendbook *bitfinex.Lendbook) (plan Plan, err error) {
+	// Sanity check
+	if conf.API == nil {
+		return plan, errors.New("Please initialize the API instance first")
+	}
+
+	now := time.Now().UTC()
+
+	// Strategy settings may depend on the time
+	conf, schedule, err := conf.Scheduled(now)
+	if err != nil {
+		return
+	}
+
+	plan = Plan{
+		Account:  conf.AccountName(),
+		Strategy: conf.Strategy.Active,
+		Schedule: schedule,
+		Currency: strings.ToLower(conf.Bitfinex.ActiveWallet),
+		Created:  now,
+	}
+
+	if len(conf.Strategy.Allocations) > 0 {
+		var names []string
+		for _, a := range conf.Strategy.Allocations {
+			names = append(names, a.Strategy)
+		}
+		plan.Strategy = strings.Join(names, "+")
+	} else if _, err = strategy.Lookup(conf.Strategy.Active); err != nil {
+		return
+	}
+
+	m, err := fetchMarket(conf, lendbook)
+	if err != nil {
+		return
+	}
+
+	plan.Snapshot = marketSnapshot(m.Market, plan.Created)
+
+	// Previews must not change the offer records, the plan keeps the strategy's copy
+	sconf := conf.StrategyConfig()
+	sconf.Owned = conf.Owned.Copy()
+	plan.owned = sconf.Owned
+
+	plan.Actions, err = strategy.Run(sconf, m.Market)
+	if err != nil {
+		return
+	}
+
+	plan.Actions = limitActions(m.Info, m.planLiquidity(plan.Actions, plan.Created, conf.Logger()))
+
+	return
+}
+
+// RunStats counts exchange actions taken during a strategy run
+type RunStats struct {
+	OffersPlaced    int
+	OffersCancelled int
+}
+
+func (rs *RunStats) offerPlaced() {
+	if rs != nil {
+		rs.OffersPlaced++
+	}
+}
+
+func (rs *RunStats) offersCancelled(n int) {
+	if rs != nil {
+		rs.OffersCancelled += n
+	}
+}
+
+// limitActions keeps the periods and rates of the lends within what the exchange accepts for the currency
+func limitActions(c exchange.CurrencyInfo, actions strategy.PlanActions) strategy.PlanActions {
+	maxRate := exchange.RateDecimal(c.MaxDailyRate * 365)
+	for i, a := range actions {
+		if a.Action != strategy.ActionLend {
+			continue
+		}
+
+		actions[i].Period = c.ClampPeriod(a.Period)
+		if c.MaxDailyRate > 0 && a.YearlyRate.Cmp(maxRate) > 0 {
+			actions[i].YearlyRate = maxRate
+		}
+	}
+
+	return actions
+}
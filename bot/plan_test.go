@@ -0,0 +1,109 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+func TestLimitActions(t *testing.T) {
+	info := exchange.CurrencyInfo{MinPeriod: 2, MaxPeriod: 30, MaxDailyRate: 1}
+
+	actions := limitActions(info, strategy.PlanActions{
+		strategy.PlanAction{Action: strategy.ActionCancelAll},
+		strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("1"), YearlyRate: exchange.MustDecimal("36.5"), Period: 60},
+		strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("1"), YearlyRate: exchange.MustDecimal("730"), Period: 1},
+	})
+
+	if actions[1].Period != 30 || actions[1].YearlyRate != exchange.MustDecimal("36.5") {
+		t.Error("Returned wrong first lend (" + strconv.Itoa(actions[1].Period) + " days @ " + actions[1].YearlyRate.String() + ")")
+	}
+
+	if actions[2].Period != 2 || actions[2].YearlyRate != exchange.MustDecimal("365") {
+		t.Error("Returned wrong second lend (" + strconv.Itoa(actions[2].Period) + " days @ " + actions[2].YearlyRate.String() + ")")
+	}
+}
+
+func TestPlan_ScheduleSwitch(t *testing.T) {
+	var conf config.Config
+	err := json.Unmarshal([]byte(`{
+		"Bitfinex": {"MaxActiveAmount": -1},
+		"Strategy": {"Active": "MarginBot", "MarginBot": {"MinDailyLendRate": 0.01, "SpreadLend": 1}},
+		"Schedule": {"Rules": [{"Name": "night", "Hours": "22-6",
+			"Strategy": {"Active": "Harmonia", "Harmonia": {"MinDailyLendRate": 0.01, "SpreadLend": 1, "DepthPctBottom": 0, "DepthPctTop": 10}}}]}
+	}`), &conf)
+	if err != nil {
+		t.Fatal("Failed to parse the config: " + err.Error())
+	}
+
+	conf.Owned = &strategy.OwnedOffers{}
+	conf.Owned.Placed("MarginBot", 1, 300)
+	conf.Owned.Placed("MarginBot", 2, 300)
+
+	// The night rule switches to Harmonia, which must take over MarginBot's offers
+	night, active, err := conf.Scheduled(time.Date(2017, 5, 15, 23, 0, 0, 0, time.UTC))
+	if err != nil || len(active) != 1 {
+		t.Fatal("Night rule not applied")
+	}
+
+	actions, err := strategy.Run(night.StrategyConfig(), testMarket())
+	if err != nil {
+		t.Fatal("Strategy failed: " + err.Error())
+	}
+
+	cancelled := map[int]bool{}
+	for _, a := range actions {
+		if a.Action == strategy.ActionCancel {
+			cancelled[a.OfferID] = true
+		}
+	}
+	if !cancelled[1] || !cancelled[2] {
+		t.Errorf("Offers of the previous strategy not re-priced (%v)", actions)
+	}
+
+	// And back in the morning
+	day, _, _ := conf.Scheduled(time.Date(2017, 5, 15, 12, 0, 0, 0, time.UTC))
+	if offers := conf.Owned.Strategies["harmonia"]; offers == nil || len(offers.Offers) != 2 {
+		t.Fatal("Offers not handed over to Harmonia")
+	}
+
+	actions, err = strategy.Run(day.StrategyConfig(), testMarket())
+	if err != nil || len(actions) < 2 || actions[0].Action != strategy.ActionCancel || actions[1].Action != strategy.ActionCancel {
+		t.Errorf("Offers of the night strategy not re-priced (%v)", actions)
+	}
+}
+
+func TestExecutePlan_CancelAll(t *testing.T) {
+	// Manual offers would be cancelled too, nothing may reach the exchange, API is nil
+	plan := Plan{Currency: "usd", Actions: strategy.PlanActions{strategy.PlanAction{Action: strategy.ActionCancelAll}}}
+	if err := (&Bot{}).executePlan(config.Config{}, plan, &RunStats{}, false); err == nil || !strings.Contains(err.Error(), "cancels all offers") {
+		t.Errorf("Plan cancelling all offers executed: %v", err)
+	}
+}
+
+func TestExecutePlan_OwnedRecords(t *testing.T) {
+	live := &strategy.OwnedOffers{}
+	live.Placed("MarginBot", 1, 300)
+
+	reconciled := live.Copy()
+	reconciled.Cancelled(1)
+
+	plan := Plan{Currency: "usd", owned: reconciled}
+	conf := config.Config{Owned: live}
+
+	if err := (&Bot{}).executePlan(conf, plan, &RunStats{}, true); err != nil || len(live.Strategies["marginbot"].Offers) != 1 {
+		t.Errorf("Dry run changed the offer records: %v", err)
+	}
+
+	if err := (&Bot{}).executePlan(conf, plan, &RunStats{}, false); err != nil || len(live.Strategies["marginbot"].Offers) != 0 {
+		t.Errorf("Executed plan did not keep the reconciled offer records: %v", err)
+	}
+}
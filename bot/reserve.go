@@ -0,0 +1,148 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+// liquidityNeed is a parsed LiquidityNeed
+type liquidityNeed struct {
+	Date   time.Time
+	Amount float64
+}
+
+// reserveAmount returns the amount that must stay unlent out of the wallet amount
+func reserveAmount(r config.ReserveConf, walletAmount float64) float64 {
+	return math.Max(r.Amount, walletAmount*r.Pct/100)
+}
+
+// upcomingNeeds returns the needs after now, sorted by date
+func upcomingNeeds(r config.ReserveConf, now time.Time) (needs []liquidityNeed, err error) {
+	for _, n := range r.Needs {
+		date, err := time.Parse("2006-01-02", n.Date)
+		if err != nil {
+			return nil, errors.New("Invalid liquidity need date \"" + n.Date + "\"")
+		}
+
+		if date.After(now) && n.Amount > 0 {
+			needs = append(needs, liquidityNeed{Date: date, Amount: n.Amount})
+		}
+	}
+
+	sort.Slice(needs, func(i, j int) bool { return needs[i].Date.Before(needs[j].Date) })
+
+	return
+}
+
+// periodLimit returns the longest period of an offer placed now that returns before the date.
+// One day is left for the offer to be taken.
+func periodLimit(now, date time.Time) int {
+	return int(date.Sub(now).Hours()/24) - 1
+}
+
+// planLiquidity restricts the plan's lends so that every upcoming need can be met:
+// on each date the wallet amount minus the needs before it, minus whatever is still lent
+// out (loans, offers left active and planned lends), must cover the need. Higher rate lends keep
+// their periods first; the others are shortened to return in time, or reduced if the date is too close.
+func (m accountMarket) planLiquidity(actions strategy.PlanActions, now time.Time, logger *log.Logger) strategy.PlanActions {
+	if len(m.Needs) == 0 {
+		return actions
+	}
+
+	// Offers that stay active after the plan
+	cancelled := map[int]bool{}
+	for _, a := range actions {
+		switch a.Action {
+		case strategy.ActionCancelAll:
+			for _, o := range m.Offers {
+				cancelled[o.ID] = true
+			}
+		case strategy.ActionCancel:
+			cancelled[a.OfferID] = true
+		}
+	}
+
+	// Lends in descending rate order
+	var lends []int
+	for i, a := range actions {
+		if a.Action == strategy.ActionLend {
+			lends = append(lends, i)
+		}
+	}
+	sort.SliceStable(lends, func(i, j int) bool { return actions[lends[i]].YearlyRate.Cmp(actions[lends[j]].YearlyRate) > 0 })
+
+	places := m.Info.AmountPlaces
+	minLoan := exchange.MinLoanDecimal(m.MinLoan, places)
+	total := m.Wallet.Amount
+
+	for _, n := range m.Needs {
+		limit := periodLimit(now, n.Date)
+
+		// Still lent out on the date
+		locked := 0.0
+		for _, l := range m.Loans {
+			if l.Expiry.After(n.Date) {
+				locked += l.Amount
+			}
+		}
+		for _, o := range m.Offers {
+			if !cancelled[o.ID] && o.Period > limit {
+				locked += o.RemainingAmount
+			}
+		}
+
+		budget := exchange.DecimalFromFloat(math.Max(0, total-locked-n.Amount)).Round(places, exchange.RoundDown)
+		total -= n.Amount
+
+		for _, i := range lends {
+			a := &actions[i]
+			if a.Amount.IsZero() || a.Period <= limit {
+				continue
+			}
+
+			if a.Amount.Cmp(budget) <= 0 {
+				budget = budget.Sub(a.Amount)
+				continue
+			}
+
+			date := n.Date.Format("2006-01-02")
+			if limit >= m.Info.MinPeriod {
+				logger.Println("\tLiquidity need of " + formatFloat(n.Amount) + " " + m.Currency + " on " + date +
+					": offer of " + a.Amount.String() + " shortened from " + strconv.Itoa(a.Period) + " to " + strconv.Itoa(limit) + " days")
+				a.Period = limit
+				continue
+			}
+
+			// Too close to the date to lend the funds at all
+			amount := budget
+			if amount.Cmp(minLoan) < 0 {
+				amount = exchange.Decimal{}
+			}
+
+			logger.Println("\tLiquidity need of " + formatFloat(n.Amount) + " " + m.Currency + " on " + date +
+				": offer of " + a.Amount.String() + " reduced to " + amount.String())
+			a.Amount = amount
+			budget = budget.Sub(amount)
+		}
+	}
+
+	// Drop the lends that were reduced to nothing
+	var result strategy.PlanActions
+	for _, a := range actions {
+		if a.Action != strategy.ActionLend || !a.Amount.IsZero() {
+			result = append(result, a)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,77 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"log"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+func TestReserveConf(t *testing.T) {
+	r := config.ReserveConf{Amount: 2, Pct: 10}
+	if v := reserveAmount(r, 10); v != 2 {
+		t.Error("Returned wrong reserve (" + strconv.FormatFloat(v, 'f', -1, 64) + ", expected: 2)")
+	}
+	if v := reserveAmount(r, 50); v != 5 {
+		t.Error("Returned wrong reserve (" + strconv.FormatFloat(v, 'f', -1, 64) + ", expected: 5)")
+	}
+
+	now := time.Date(2016, 3, 10, 12, 0, 0, 0, time.UTC)
+	r.Needs = []config.LiquidityNeed{{Date: "2016-04-01", Amount: 1}, {Date: "2016-03-01", Amount: 1}, {Date: "2016-03-20", Amount: 2}}
+
+	needs, err := upcomingNeeds(r, now)
+	if err != nil || len(needs) != 2 || needs[0].Amount != 2 || needs[1].Amount != 1 {
+		t.Errorf("Returned wrong needs %v", needs)
+	}
+
+	r.Needs = []config.LiquidityNeed{{Date: "1st of April", Amount: 1}}
+	if _, err := upcomingNeeds(r, now); err == nil {
+		t.Error("Invalid date accepted")
+	}
+}
+
+func TestPlanLiquidity(t *testing.T) {
+	now := time.Date(2016, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	m := accountMarket{Market: testMarket()}
+	m.Wallet.Amount = 10
+	m.Offers = nil
+	m.MinLoan = 0.1
+	m.Loans = Loans{Loan{Currency: "usd", Amount: 3, Expiry: now.AddDate(0, 0, 20)}}
+
+	actions := func() strategy.PlanActions {
+		return strategy.PlanActions{
+			strategy.PlanAction{Action: strategy.ActionCancelAll},
+			strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("4"), YearlyRate: exchange.MustDecimal("182.5"), Period: 30},
+			strategy.PlanAction{Action: strategy.ActionLend, Amount: exchange.MustDecimal("2"), YearlyRate: exchange.MustDecimal("365"), Period: 30},
+		}
+	}
+
+	// 10 - 3 lent - 5 needed leaves 2 that may stay lent: the higher rate offer keeps its period
+	m.Needs = []liquidityNeed{{Date: now.AddDate(0, 0, 10), Amount: 5}}
+	result := m.planLiquidity(actions(), now, log.Default())
+	if len(result) != 3 || result[1].Period != 9 || result[2].Period != 30 {
+		t.Errorf("Returned wrong actions %+v", result)
+	}
+
+	// Too close to shorten the offers, the other one is not made
+	m.Needs = []liquidityNeed{{Date: now.AddDate(0, 0, 2), Amount: 5}}
+	result = m.planLiquidity(actions(), now, log.Default())
+	if len(result) != 2 || result[1].Amount != exchange.MustDecimal("2") || result[1].Period != 30 {
+		t.Errorf("Returned wrong actions %+v", result)
+	}
+
+	// Loans returning in time count towards the need
+	m.Loans[0].Expiry = now.AddDate(0, 0, 5)
+	m.Needs = []liquidityNeed{{Date: now.AddDate(0, 0, 10), Amount: 4}}
+	result = m.planLiquidity(actions(), now, log.Default())
+	if len(result) != 3 || result[1].Period != 30 || result[2].Period != 30 {
+		t.Errorf("Returned wrong actions %+v", result)
+	}
+}
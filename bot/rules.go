@@ -0,0 +1,98 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+)
+
+// configMetrics derives metrics from the active strategy's settings
+func configMetrics(conf config.Config, dailyFRR float64, metrics map[string]float64) {
+	switch strings.ToLower(conf.Strategy.Active) {
+	case "marginbot":
+		metrics[notify.MetricMinDailyRate] = conf.Strategy.MarginBot.MinDailyLendRate
+		metrics[notify.MetricHighHoldMargin] = conf.Strategy.MarginBot.HighHoldDailyRate - conf.Strategy.MarginBot.MinDailyLendRate
+	case "cascadebot":
+		metrics[notify.MetricMinDailyRate] = conf.Strategy.CascadeBot.MinDailyLendRate
+		if dailyFRR > 0 {
+			metrics[notify.MetricStartDailyRate] = dailyFRR + conf.Strategy.CascadeBot.StartDailyLendRateFRRInc
+		}
+	case "harmonia":
+		metrics[notify.MetricMinDailyRate] = conf.Strategy.Harmonia.MinDailyLendRate
+	}
+}
+
+// evaluateRules fires alerts for rules whose condition held long enough and are not cooling down.
+// Metrics missing from the map (e.g. unknown FRR) are not evaluated.
+func evaluateRules(conf config.Config, as *AccountState, currency string, metrics map[string]float64, now time.Time) {
+	if as.RuleSince == nil {
+		as.RuleSince = map[string]time.Time{}
+	}
+	if as.RuleFired == nil {
+		as.RuleFired = map[string]time.Time{}
+	}
+
+	for _, r := range conf.Notifications.AlertRules() {
+		value, ok := metrics[r.Metric]
+		if r.Disabled || !ok {
+			continue
+		}
+
+		if !r.Matches(value) {
+			delete(as.RuleSince, r.Name)
+			continue
+		}
+
+		since, ok := as.RuleSince[r.Name]
+		if !ok {
+			since = now
+			as.RuleSince[r.Name] = since
+		}
+
+		if now.Sub(since) < time.Duration(r.ForMinutes*float64(time.Minute)) {
+			continue
+		}
+
+		cooldown := time.Duration(r.CooldownMinutes * float64(time.Minute))
+		if cooldown <= 0 {
+			// Fire on every run, do not fall back to the default dedup interval
+			cooldown = time.Nanosecond
+		}
+		if last, ok := as.RuleFired[r.Name]; ok && now.Sub(last) < cooldown {
+			continue
+		}
+
+		as.RuleFired[r.Name] = now
+
+		msg := r.Message
+		if msg == "" {
+			msg = "Rule " + r.Name + " triggered"
+		}
+		msg += " (" + r.Metric + " = " + strconv.FormatFloat(value, 'f', -1, 64) + ", " + r.Op + " " +
+			strconv.FormatFloat(r.Threshold, 'f', -1, 64) + ")"
+
+		severity := r.Severity
+		if severity == "" {
+			severity = notify.SeverityWarning
+		}
+
+		conf.Logger().Println("\t" + strings.ToUpper(severity) + ": " + msg)
+
+		sendEventTo(conf, as, notify.Event{
+			Type:     r.EventType(),
+			Account:  conf.AccountName(),
+			Currency: currency,
+			Severity: severity,
+			Message:  msg,
+			Value:    value,
+			Time:     now,
+			Key:      notify.EventAlert + " " + r.Name,
+			Dedup:    cooldown,
+		}, r.Routes)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+// webhookSink collects events posted to a local webhook
+func webhookSink() (srv *httptest.Server, events *[]notify.Event) {
+	events = &[]notify.Event{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		e := notify.Event{}
+		json.Unmarshal(body, &e)
+		*events = append(*events, e)
+	}))
+
+	return
+}
+
+func TestEvaluateRules_ForMinutesAndCooldown(t *testing.T) {
+	srv, events := webhookSink()
+	defer srv.Close()
+
+	conf := config.Config{Name: "acc", Notifications: notify.NotificationsConf{
+		Webhooks: []notify.WebhookConf{{URL: srv.URL}},
+		Rules: []notify.AlertRuleConf{
+			{Name: "failing", Metric: notify.MetricFailedRuns, Op: ">=", Threshold: 3, Severity: notify.SeverityCritical, CooldownMinutes: 60},
+			{Name: "idle", Metric: notify.MetricIdleFunds, Op: ">", Threshold: 1, ForMinutes: 30},
+		},
+	}}
+	as := &AccountState{}
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Idle condition starts holding, failed runs below threshold
+	evaluateRules(conf, as, "btc", map[string]float64{notify.MetricFailedRuns: 2, notify.MetricIdleFunds: 2}, now)
+	if len(*events) != 0 {
+		t.Fatal("Sent wrong number of alerts (" + strconv.Itoa(len(*events)) + ", expected: 0)")
+	}
+
+	// Both conditions hold long enough
+	evaluateRules(conf, as, "btc", map[string]float64{notify.MetricFailedRuns: 3, notify.MetricIdleFunds: 2}, now.Add(30*time.Minute))
+	if len(*events) != 2 {
+		t.Fatal("Sent wrong number of alerts (" + strconv.Itoa(len(*events)) + ", expected: 2)")
+	}
+
+	if (*events)[0].Type != notify.EventAlert || (*events)[0].Severity != notify.SeverityCritical {
+		t.Errorf("Sent wrong alert %v (expected: critical alert)", (*events)[0])
+	}
+
+	// Failing rule is cooling down, idle rule (no cooldown) fires again
+	evaluateRules(conf, as, "btc", map[string]float64{notify.MetricFailedRuns: 4, notify.MetricIdleFunds: 2}, now.Add(40*time.Minute))
+	if len(*events) != 3 {
+		t.Fatal("Sent wrong number of alerts (" + strconv.Itoa(len(*events)) + ", expected: 3)")
+	}
+
+	// Condition stops holding => ForMinutes timer is reset
+	evaluateRules(conf, as, "btc", map[string]float64{notify.MetricIdleFunds: 0}, now.Add(50*time.Minute))
+	evaluateRules(conf, as, "btc", map[string]float64{notify.MetricIdleFunds: 2}, now.Add(60*time.Minute))
+	if len(*events) != 3 {
+		t.Error("Sent wrong number of alerts (" + strconv.Itoa(len(*events)) + ", expected: 3)")
+	}
+}
+
+func TestEvaluateRules_DefaultsAndRouting(t *testing.T) {
+	srvA, eventsA := webhookSink()
+	defer srvA.Close()
+	srvB, eventsB := webhookSink()
+	defer srvB.Close()
+
+	conf := config.Config{Name: "acc", Strategy: strategy.Conf{Active: "MarginBot", MarginBot: strategy.MarginBotConf{MinDailyLendRate: 0.001, HighHoldDailyRate: 0.05}},
+		Notifications: notify.NotificationsConf{
+			Webhooks: []notify.WebhookConf{{Name: "a", URL: srvA.URL}, {Name: "b", URL: srvB.URL}},
+			Rules: []notify.AlertRuleConf{
+				// Route the default low rate check to "b" only
+				{Name: "low_min_daily_rate", Metric: notify.MetricMinDailyRate, Op: "<=", Threshold: 0.003, Sinks: []string{"b"}},
+			},
+		}}
+	as := &AccountState{}
+
+	metrics := map[string]float64{}
+	configMetrics(conf, 0, metrics)
+	evaluateRules(conf, as, "btc", metrics, time.Now())
+
+	if len(*eventsA) != 0 || len(*eventsB) != 1 {
+		t.Fatal("Sent wrong number of alerts (" + strconv.Itoa(len(*eventsA)) + " a, " + strconv.Itoa(len(*eventsB)) + " b, expected: 0 a, 1 b)")
+	}
+
+	// Disabling a default rule
+	conf.Notifications.Rules[0].Disabled = true
+	as = &AccountState{}
+	evaluateRules(conf, as, "btc", metrics, time.Now())
+
+	if len(*eventsB) != 1 {
+		t.Error("Sent alert for disabled rule")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+// State is the bot's local data kept between runs
+type State struct {
+	Accounts         map[string]*AccountState
+	PausedCurrencies []string
+	Prices           map[string]exchange.PriceQuote // Last known prices, by "from/to"
+}
+
+// AccountState ...
+type AccountState struct {
+	Loans          Loans
+	LoansUpdated   time.Time
+	Interest       InterestPayments
+	InterestSynced map[string]time.Time
+
+	// Notifications
+	Notified   map[string]time.Time
+	LastFRR    float64
+	Digest     DigestState
+	FailedRuns int
+	RuleSince  map[string]time.Time
+	RuleFired  map[string]time.Time
+
+	Paused        bool
+	LastRun       *RunResult
+	LastDailyRate float64 // Average offer rate of the last executed plan
+	Owned         strategy.OwnedOffers
+
+	// Dashboard
+	History []HistoryPoint
+	Market  *MarketSnapshot
+	Runs    []RunResult
+}
+
+func loadState(path string) (st *State, err error) {
+	st = &State{Accounts: map[string]*AccountState{}, Prices: map[string]exchange.PriceQuote{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		// First run
+		return st, nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, st)
+	if st.Accounts == nil {
+		st.Accounts = map[string]*AccountState{}
+	}
+	if st.Prices == nil {
+		st.Prices = map[string]exchange.PriceQuote{}
+	}
+
+	return
+}
+
+func (st *State) save(path string) (err error) {
+	data, err := json.MarshalIndent(st, "", "\t")
+	if err != nil {
+		return
+	}
+
+	// Write to a temporary file first so that a crash never leaves a truncated state behind
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Account returns the local state of the named account, creating it if needed
+func (st *State) Account(name string) *AccountState {
+	as, ok := st.Accounts[name]
+	if !ok {
+		as = &AccountState{}
+		st.Accounts[name] = as
+	}
+
+	return as
+}
+
+// paused returns true if lending is paused for the account or its active currency
+func (st *State) paused(conf config.Config) bool {
+	return st.Account(conf.AccountName()).Paused ||
+		containsString(st.PausedCurrencies, strings.ToLower(conf.Bitfinex.ActiveWallet))
+}
@@ -0,0 +1,72 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// SweepTransfer ...
+type SweepTransfer struct {
+	From   string
+	Amount exchange.Decimal
+}
+
+// sweepTransfers returns what to move from each source wallet, amounts are rounded
+// down to the given decimal places
+func sweepTransfers(c config.SweepConf, balance bitfinex.WalletBalances, currency string, places int) (transfers []SweepTransfer) {
+	floor := exchange.DecimalFromFloat(c.Floor)
+	minAmount := exchange.DecimalFromFloat(c.MinAmount)
+	remaining := exchange.DecimalFromFloat(c.MaxAmount)
+
+	for _, w := range c.Wallets {
+		w = strings.ToLower(w)
+		if w == "deposit" {
+			continue
+		}
+
+		amount := exchange.AmountDecimal(balance[bitfinex.WalletKey{Type: w, Currency: currency}].Available, places).Sub(floor)
+		if c.MaxAmount > 0 {
+			amount = exchange.MinDecimal(amount, remaining)
+		}
+
+		if amount.Cmp(exchange.Decimal{}) <= 0 || amount.Cmp(minAmount) < 0 {
+			continue
+		}
+
+		transfers = append(transfers, SweepTransfer{From: w, Amount: amount})
+		remaining = remaining.Sub(amount)
+	}
+
+	return
+}
+
+// sweep moves the idle funds into the deposit wallet
+func sweep(conf config.Config, balance bitfinex.WalletBalances, dryRun bool) (err error) {
+	if len(conf.Sweep.Wallets) == 0 {
+		return
+	}
+
+	currency := strings.ToLower(conf.Bitfinex.ActiveWallet)
+	info := conf.CurrencyInfo(currency)
+
+	for _, t := range sweepTransfers(conf.Sweep, balance, currency, info.AmountPlaces) {
+		conf.Logger().Println("\tSweeping " + t.Amount.String() + " " + currency + " from the " + t.From + " wallet to the deposit wallet")
+
+		if dryRun {
+			continue
+		}
+
+		err = conf.ExtAPI.Transfer(currency, t.Amount, t.From, "deposit")
+		if err != nil {
+			return errors.New("Failed to sweep the " + t.From + " wallet: " + err.Error())
+		}
+	}
+
+	return
+}
@@ -0,0 +1,95 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package bot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestSweepConf_Transfers(t *testing.T) {
+	balance := bitfinex.WalletBalances{
+		bitfinex.WalletKey{Type: "exchange", Currency: "btc"}: bitfinex.WalletBalance{Amount: 3, Available: 2.12345678},
+		bitfinex.WalletKey{Type: "trading", Currency: "btc"}:  bitfinex.WalletBalance{Amount: 5, Available: 1},
+		bitfinex.WalletKey{Type: "trading", Currency: "usd"}:  bitfinex.WalletBalance{Amount: 100, Available: 100},
+		bitfinex.WalletKey{Type: "deposit", Currency: "btc"}:  bitfinex.WalletBalance{Amount: 1, Available: 1},
+	}
+
+	tests := []struct {
+		conf      config.SweepConf
+		transfers []SweepTransfer
+	}{
+		{config.SweepConf{}, nil},
+		{config.SweepConf{Wallets: []string{"Exchange", "trading", "deposit"}},
+			[]SweepTransfer{{"exchange", exchange.MustDecimal("2.12345678")}, {"trading", exchange.MustDecimal("1")}}},
+		{config.SweepConf{Wallets: []string{"exchange", "trading"}, Floor: 0.5},
+			[]SweepTransfer{{"exchange", exchange.MustDecimal("1.62345678")}, {"trading", exchange.MustDecimal("0.5")}}},
+		{config.SweepConf{Wallets: []string{"exchange", "trading"}, Floor: 0.5, MinAmount: 1},
+			[]SweepTransfer{{"exchange", exchange.MustDecimal("1.62345678")}}},
+		{config.SweepConf{Wallets: []string{"exchange", "trading"}, MaxAmount: 2.5},
+			[]SweepTransfer{{"exchange", exchange.MustDecimal("2.12345678")}, {"trading", exchange.MustDecimal("0.37654322")}}},
+		{config.SweepConf{Wallets: []string{"trading"}, Floor: 2}, nil},
+	}
+
+	for i, tt := range tests {
+		transfers := sweepTransfers(tt.conf, balance, "btc", exchange.DecimalPlaces)
+		if len(transfers) != len(tt.transfers) {
+			t.Error("Case " + strconv.Itoa(i) + ": returned wrong number of transfers (" + strconv.Itoa(len(transfers)) +
+				", expected: " + strconv.Itoa(len(tt.transfers)) + ")")
+			continue
+		}
+
+		for j := range transfers {
+			if transfers[j] != tt.transfers[j] {
+				t.Error("Case " + strconv.Itoa(i) + ": returned wrong transfer (" + transfers[j].Amount.String() + " from " + transfers[j].From +
+					", expected: " + tt.transfers[j].Amount.String() + " from " + tt.transfers[j].From + ")")
+			}
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	var payloads []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payloadJSON, _ := base64.StdEncoding.DecodeString(r.Header.Get("X-BFX-PAYLOAD"))
+		payload := map[string]interface{}{}
+		json.Unmarshal(payloadJSON, &payload)
+		payloads = append(payloads, payload)
+
+		w.Write([]byte(`[{"status":"success","message":"1.5 Bitcoin transfered from Exchange to Deposit"}]`))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { exchange.APIURL = url }(exchange.APIURL)
+	exchange.APIURL = srv.URL + "/v1/"
+
+	conf := config.Config{
+		Bitfinex: config.BitfinexConf{ActiveWallet: "BTC"},
+		Sweep:    config.SweepConf{Wallets: []string{"exchange"}},
+		ExtAPI:   exchange.NewBitfinexExt("key", "secret"),
+	}
+	balance := bitfinex.WalletBalances{bitfinex.WalletKey{Type: "exchange", Currency: "btc"}: bitfinex.WalletBalance{Amount: 1.5, Available: 1.5}}
+
+	err := sweep(conf, balance, true)
+	if err != nil || len(payloads) != 0 {
+		t.Fatal("Funds moved in a dry run")
+	}
+
+	err = sweep(conf, balance, false)
+	if err != nil {
+		t.Fatal("Sweep failed: " + err.Error())
+	}
+
+	if len(payloads) != 1 || payloads[0]["request"] != "/v1/transfer" || payloads[0]["amount"] != "1.5" || payloads[0]["currency"] != "BTC" ||
+		payloads[0]["walletfrom"] != "exchange" || payloads[0]["walletto"] != "deposit" {
+		t.Errorf("Wrong transfer requests %v", payloads)
+	}
+}
@@ -1,3 +0,0 @@
-// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
-
-package main
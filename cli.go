@@ -0,0 +1,709 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Inspection and manual operation commands
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/bot"
+	"github.com/eAndrius/BitfinexLendingBot/config"
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// commandArgs parses the command line arguments following the command name.
+// All global flags are accepted after the command too, so that both
+// "--json offers" and "offers --json" work.
+func commandArgs(args []string) (positional []string, err error) {
+	fs := flag.NewFlagSet(flag.CommandLine.Name(), flag.ContinueOnError)
+	fs.Usage = flag.Usage
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+
+	for {
+		err = fs.Parse(args)
+		if err != nil {
+			return
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// selectAccounts keeps only the account chosen with --account, if any
+func selectAccounts(confs config.Configs, name string) (selected config.Configs, err error) {
+	if name == "" {
+		return confs, nil
+	}
+
+	for _, c := range confs {
+		if c.AccountName() == name {
+			selected = append(selected, c)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("Unknown account: " + name)
+	}
+
+	return
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// cmdStatus prints balances, offers and the last run of every account
+func cmdStatus(b *bot.Bot, w io.Writer) (err error) {
+	var all []bot.AccountStatus
+	for _, conf := range b.Confs {
+		all = append(all, b.Status(conf))
+	}
+
+	if *jsonOutput {
+		return encodeJSON(w, all)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Account\tStrategy\tCurrency\tPaused\tWallet\tAvailable\tOffers\tOffered\tLast run")
+	for _, s := range all {
+		wallet, available := 0.0, 0.0
+		for _, bal := range s.Balances {
+			if bal.Type == "deposit" && strings.ToLower(bal.Currency) == s.Currency {
+				wallet, available = bal.Amount, bal.Available
+			}
+		}
+
+		offered := 0.0
+		for _, o := range s.Offers {
+			if strings.ToLower(o.Currency) == s.Currency {
+				offered += o.RemainingAmount
+			}
+		}
+
+		lastRun := "-"
+		if s.LastRun != nil {
+			lastRun = s.LastRun.Time.Local().Format("2006-01-02 15:04")
+			switch {
+			case s.LastRun.Error != "":
+				lastRun += " (failed: " + s.LastRun.Error + ")"
+			case s.LastRun.Skipped != "":
+				lastRun += " (skipped: " + s.LastRun.Skipped + ")"
+			case s.LastRun.DryRun:
+				lastRun += " (dry run)"
+			}
+		}
+
+		if s.Error != "" {
+			lastRun += " [" + s.Error + "]"
+		}
+
+		fmt.Fprintln(tw, s.Name+"\t"+s.Strategy+"\t"+s.Currency+"\t"+strconv.FormatBool(s.Paused)+"\t"+
+			formatFloat(wallet)+"\t"+formatFloat(available)+"\t"+
+			strconv.Itoa(len(s.Offers))+"\t"+formatFloat(offered)+"\t"+lastRun)
+	}
+
+	return tw.Flush()
+}
+
+// AccountOffer ...
+type AccountOffer struct {
+	Account string
+	bitfinex.Offer
+}
+
+// cmdOffers prints active lend offers of every account
+func cmdOffers(confs config.Configs, w io.Writer) (err error) {
+	var all []AccountOffer
+	for _, conf := range confs {
+		offers, err := conf.API.ActiveOffers()
+		if err != nil {
+			return errors.New("Failed to get active offers of " + conf.AccountName() + ": " + err.Error())
+		}
+
+		for _, o := range offers {
+			if strings.ToLower(o.Direction) == bitfinex.LEND {
+				all = append(all, AccountOffer{Account: conf.AccountName(), Offer: o})
+			}
+		}
+	}
+
+	if *jsonOutput {
+		return encodeJSON(w, all)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Account\tID\tCurrency\tRemaining\tRate (%/day)\tPeriod\tPlaced")
+	for _, o := range all {
+		fmt.Fprintln(tw, o.Account+"\t"+strconv.Itoa(o.ID)+"\t"+strings.ToLower(o.Currency)+"\t"+
+			formatFloat(o.RemainingAmount)+"\t"+
+			strconv.FormatFloat(o.Rate/365, 'f', 6, 64)+"\t"+
+			strconv.Itoa(o.Period)+"\t"+
+			time.Unix(int64(o.Timestamp), 0).Format("2006-01-02 15:04"))
+	}
+
+	return tw.Flush()
+}
+
+// cmdBalances prints all wallet balances of every account
+func cmdBalances(confs config.Configs, w io.Writer) (err error) {
+	type accountBalances struct {
+		Account  string
+		Balances []bot.Balance
+	}
+
+	var all []accountBalances
+	for _, conf := range confs {
+		balance, err := conf.API.WalletBalances()
+		if err != nil {
+			return errors.New("Failed to get wallet funds of " + conf.AccountName() + ": " + err.Error())
+		}
+
+		all = append(all, accountBalances{Account: conf.AccountName(), Balances: bot.BalancesList(balance)})
+	}
+
+	if *jsonOutput {
+		return encodeJSON(w, all)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Account\tWallet\tCurrency\tAmount\tAvailable")
+	for _, ab := range all {
+		for _, bal := range ab.Balances {
+			fmt.Fprintln(tw, ab.Account+"\t"+bal.Type+"\t"+strings.ToLower(bal.Currency)+"\t"+
+				formatFloat(bal.Amount)+"\t"+formatFloat(bal.Available))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// cmdLendbook prints the lend side of the currency's lendbook with cumulative depth
+func cmdLendbook(confs config.Configs, args []string, w io.Writer) (err error) {
+	if len(args) != 1 {
+		return errors.New("Usage: lendbook <currency>")
+	}
+	if len(confs) == 0 {
+		return errors.New("No accounts configured")
+	}
+
+	currency := strings.ToLower(args[0])
+
+	lendbook, err := confs[0].API.Lendbook(currency, 0, 10000)
+	if err != nil {
+		return errors.New("Failed to get lendbook: " + err.Error())
+	}
+
+	var levels []exchange.DepthLevel
+	for _, o := range lendbook.Asks {
+		levels = append(levels, exchange.DepthLevel{Rate: o.Rate, Amount: o.Amount, FRR: o.FRR})
+	}
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].Rate < levels[j].Rate })
+
+	if *jsonOutput {
+		return encodeJSON(w, levels)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Rate (%/day)\tRate (%/year)\tAmount\tCumulative\tFRR")
+	depth := 0.0
+	for _, l := range levels {
+		depth += l.Amount
+
+		frr := ""
+		if l.FRR {
+			frr = "yes"
+		}
+
+		fmt.Fprintln(tw, strconv.FormatFloat(l.Rate/365, 'f', 6, 64)+"\t"+
+			strconv.FormatFloat(l.Rate, 'f', 4, 64)+"\t"+
+			formatFloat(l.Amount)+"\t"+
+			strconv.FormatFloat(depth, 'f', 2, 64)+"\t"+frr)
+	}
+
+	return tw.Flush()
+}
+
+// cmdCancel cancels a single lend offer (--id) or all lend offers of the active currency (--all) of an account
+func cmdCancel(confs config.Configs, w io.Writer) (err error) {
+	if *cancelAll == (*cancelID == 0) {
+		return errors.New("Usage: cancel --all | --id=<offer id>")
+	}
+	if len(confs) != 1 {
+		return errors.New("Several accounts configured, select one with --account")
+	}
+
+	conf := confs[0]
+	currency := strings.ToLower(conf.Bitfinex.ActiveWallet)
+
+	offers, err := conf.API.ActiveOffers()
+	if err != nil {
+		return errors.New("Failed to get active offers: " + err.Error())
+	}
+
+	var ids []int
+	for _, o := range offers {
+		if strings.ToLower(o.Currency) == currency && strings.ToLower(o.Direction) == bitfinex.LEND && (*cancelAll || o.ID == *cancelID) {
+			ids = append(ids, o.ID)
+		}
+	}
+
+	if !*cancelAll && len(ids) == 0 {
+		return errors.New("No active " + currency + " lend offer with ID " + strconv.Itoa(*cancelID))
+	}
+
+	var cancelled []int
+	for _, id := range ids {
+		if !*dryRun {
+			err = conf.API.CancelOffer(id)
+			if err != nil {
+				err = errors.New("Failed to cancel offer " + strconv.Itoa(id) + ": " + err.Error())
+				break
+			}
+
+			conf.Owned.Cancelled(id)
+		}
+
+		cancelled = append(cancelled, id)
+	}
+
+	if *jsonOutput {
+		encodeJSON(w, map[string]interface{}{"Account": conf.AccountName(), "DryRun": *dryRun, "Cancelled": cancelled})
+		return
+	}
+
+	for _, id := range cancelled {
+		if *dryRun {
+			fmt.Fprintln(w, "Would cancel offer "+strconv.Itoa(id))
+		} else {
+			fmt.Fprintln(w, "Cancelled offer "+strconv.Itoa(id))
+		}
+	}
+
+	return
+}
+
+// cmdAdopt hands lend offers created elsewhere (manually or by an earlier version) over to a strategy,
+// which then cancels and re-prices them like its own
+func cmdAdopt(confs config.Configs, args []string, w io.Writer) (err error) {
+	if *cancelAll == (*cancelID == 0) || len(args) > 1 {
+		return errors.New("Usage: adopt [strategy] --all | --id=<offer id>")
+	}
+	if len(confs) != 1 {
+		return errors.New("Several accounts configured, select one with --account")
+	}
+
+	conf := confs[0]
+	currency := strings.ToLower(conf.Bitfinex.ActiveWallet)
+
+	// Offers go to the active strategy unless allocated between several
+	var strategies []string
+	for _, a := range conf.Strategy.Allocations {
+		strategies = append(strategies, strings.ToLower(a.Strategy))
+	}
+	if len(strategies) == 0 {
+		strategies = []string{strings.ToLower(conf.Strategy.Active)}
+	}
+
+	owner := strategies[0]
+	if len(args) == 1 {
+		owner = strings.ToLower(args[0])
+	} else if len(strategies) > 1 {
+		return errors.New("Several strategies allocated, select one: adopt <strategy>")
+	}
+	if !containsString(strategies, owner) {
+		return errors.New("Strategy " + owner + " is not used by " + conf.AccountName())
+	}
+
+	offers, err := conf.API.ActiveOffers()
+	if err != nil {
+		return errors.New("Failed to get active offers: " + err.Error())
+	}
+
+	var adopted []int
+	for _, o := range offers {
+		if strings.ToLower(o.Currency) != currency || strings.ToLower(o.Direction) != bitfinex.LEND || !(*cancelAll || o.ID == *cancelID) {
+			continue
+		}
+
+		if !*dryRun {
+			conf.Owned.Adopt(owner, o)
+		}
+
+		adopted = append(adopted, o.ID)
+	}
+
+	if !*cancelAll && len(adopted) == 0 {
+		return errors.New("No active " + currency + " lend offer with ID " + strconv.Itoa(*cancelID))
+	}
+
+	if *jsonOutput {
+		return encodeJSON(w, map[string]interface{}{"Account": conf.AccountName(), "DryRun": *dryRun, "Strategy": owner, "Adopted": adopted})
+	}
+
+	for _, id := range adopted {
+		if *dryRun {
+			fmt.Fprintln(w, "Would adopt offer "+strconv.Itoa(id)+" by "+owner)
+		} else {
+			fmt.Fprintln(w, "Adopted offer "+strconv.Itoa(id)+" by "+owner)
+		}
+	}
+
+	return
+}
+
+// cmdPlan prints what the active strategy of every account would do right now
+func cmdPlan(confs config.Configs, w io.Writer) (err error) {
+	var plans []bot.Plan
+	for _, conf := range confs {
+		plan, err := bot.PlanStrategy(conf)
+		if err != nil {
+			return errors.New("Failed to plan " + conf.AccountName() + ": " + err.Error())
+		}
+
+		plans = append(plans, plan)
+	}
+
+	if *planOut != "" {
+		err = bot.WritePlans(*planOut, plans)
+		if err != nil {
+			return errors.New("Failed to write plan file: " + err.Error())
+		}
+	}
+
+	if *jsonOutput {
+		return encodeJSON(w, plans)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, p := range plans {
+		fmt.Fprintln(tw, "Account: "+p.Account+" ("+p.Strategy+", "+p.Currency+")")
+		if p.Snapshot != nil {
+			fmt.Fprintln(tw, "Market snapshot: "+p.Snapshot.Hash)
+		}
+		fmt.Fprintln(tw, "#\tAction\tOffer ID\tAmount\tRate (%/day)\tPeriod")
+		for i, a := range p.Actions {
+			action, id, amount, rate, period := a.Action, "", "", "", ""
+			if a.Strategy != "" {
+				action += " (" + a.Strategy + ")"
+			}
+			if a.OfferID != 0 {
+				id = strconv.Itoa(a.OfferID)
+			}
+			if a.Action == strategy.ActionLend {
+				amount = a.Amount.String()
+				rate = strconv.FormatFloat(a.YearlyRate.Float64()/365, 'f', 6, 64)
+				period = strconv.Itoa(a.Period)
+			}
+
+			fmt.Fprintln(tw, strconv.Itoa(i+1)+"\t"+action+"\t"+id+"\t"+amount+"\t"+rate+"\t"+period)
+		}
+
+		// Derivations go below the table to keep its columns aligned
+		for i, a := range p.Actions {
+			if len(a.Explain) == 0 {
+				continue
+			}
+
+			fmt.Fprintln(tw, "\n#"+strconv.Itoa(i+1)+" "+a.Action+":")
+			for _, s := range a.Explain {
+				fmt.Fprintln(tw, "  "+s.String())
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// AccountProblems ...
+type AccountProblems struct {
+	Account  string
+	Problems []string
+}
+
+// cmdValidate checks the configuration of every account without contacting the exchange
+func cmdValidate(confs config.Configs, w io.Writer) (err error) {
+	var all []AccountProblems
+	n := 0
+	for _, conf := range confs {
+		problems := conf.Validate()
+		n += len(problems)
+		all = append(all, AccountProblems{Account: conf.AccountName(), Problems: problems})
+	}
+
+	if *jsonOutput {
+		err = encodeJSON(w, all)
+	} else {
+		for _, ap := range all {
+			if len(ap.Problems) == 0 {
+				fmt.Fprintln(w, ap.Account+": OK")
+				continue
+			}
+
+			fmt.Fprintln(w, ap.Account+":")
+			for _, p := range ap.Problems {
+				fmt.Fprintln(w, "\t"+p)
+			}
+		}
+	}
+
+	if err == nil && n > 0 {
+		err = errors.New(strconv.Itoa(n) + " configuration problem(s) found")
+	}
+
+	return
+}
+
+// cmdReport syncs interest payments and prints the earnings report for every account
+func cmdReport(confs config.Configs, st *bot.State, w io.Writer) (err error) {
+	until := time.Now().UTC()
+	since := until.AddDate(0, 0, -30)
+
+	if *reportSince != "" {
+		since, err = time.Parse("2006-01-02", *reportSince)
+		if err != nil {
+			return errors.New("Invalid --since date: " + err.Error())
+		}
+	}
+
+	if *reportUntil != "" {
+		until, err = time.Parse("2006-01-02", *reportUntil)
+		if err != nil {
+			return errors.New("Invalid --until date: " + err.Error())
+		}
+	}
+
+	var rows bot.EarningsRows
+	for _, conf := range confs {
+		as := st.Account(conf.AccountName())
+
+		err = bot.UpdateInterest(conf, as)
+		if err != nil {
+			log.Println("WARNING: Failed to update interest payments, using last known: " + err.Error())
+		}
+
+		rows = append(rows, bot.EarningsReport(conf.AccountName(), as.Interest, conf.Bitfinex.LendingFeePct, since, until)...)
+	}
+
+	reportCurrency := strings.ToLower(*reportCur)
+	if reportCurrency != "usd" && len(confs) > 0 {
+		// USD values are converted at the current price
+		price, err := confs[0].Price("usd", reportCurrency)
+		if err != nil {
+			return errors.New("Failed to get " + reportCurrency + " price: " + err.Error())
+		}
+
+		for i := range rows {
+			rows[i].Value = rows[i].USD * price
+			rows[i].ValueCurrency = reportCurrency
+		}
+	}
+
+	return writeEarnings(w, rows)
+}
+
+func writeEarnings(w io.Writer, rows bot.EarningsRows) (err error) {
+	if *jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	// Extra column for a reporting currency other than USD
+	valueCurrency := ""
+	if len(rows) > 0 {
+		valueCurrency = rows[0].ValueCurrency
+	}
+
+	if *csvOutput {
+		cw := csv.NewWriter(w)
+		header := []string{"account", "currency", "date", "interest", "fees", "apr", "usd"}
+		if valueCurrency != "" {
+			header = append(header, valueCurrency)
+		}
+		cw.Write(header)
+
+		for _, r := range rows {
+			record := []string{r.Account, r.Currency, r.Date,
+				strconv.FormatFloat(r.Interest, 'f', -1, 64),
+				strconv.FormatFloat(r.Fees, 'f', -1, 64),
+				strconv.FormatFloat(r.APR, 'f', 4, 64),
+				strconv.FormatFloat(r.USD, 'f', 2, 64)}
+			if valueCurrency != "" {
+				record = append(record, strconv.FormatFloat(r.Value, 'f', 2, 64))
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "Account\tCurrency\tDate\tInterest\tFees\tAPR (%)\tUSD"
+	if valueCurrency != "" {
+		header += "\t" + strings.ToUpper(valueCurrency)
+	}
+	fmt.Fprintln(tw, header)
+
+	for _, r := range rows {
+		line := r.Account + "\t" + r.Currency + "\t" + r.Date + "\t" +
+			strconv.FormatFloat(r.Interest, 'f', 8, 64) + "\t" +
+			strconv.FormatFloat(r.Fees, 'f', 8, 64) + "\t" +
+			strconv.FormatFloat(r.APR, 'f', 2, 64) + "\t" +
+			strconv.FormatFloat(r.USD, 'f', 2, 64)
+		if valueCurrency != "" {
+			line += "\t" + strconv.FormatFloat(r.Value, 'f', 2, 64)
+		}
+		fmt.Fprintln(tw, line)
+	}
+
+	return tw.Flush()
+}
+
+// AccountLoans ...
+type AccountLoans struct {
+	Account  string
+	Loans    bot.Loans
+	Schedule bot.MaturitySchedule
+}
+
+// cmdLoans refreshes and prints active loans with their maturity schedule for every account
+func cmdLoans(confs config.Configs, st *bot.State, w io.Writer) (err error) {
+	var all []AccountLoans
+
+	for _, conf := range confs {
+		as := st.Account(conf.AccountName())
+
+		err = bot.UpdateLoans(conf, as)
+		if err != nil {
+			log.Println("WARNING: Failed to update active loans, using last known: " + err.Error())
+		}
+
+		all = append(all, AccountLoans{Account: conf.AccountName(), Loans: as.Loans, Schedule: bot.LoanMaturitySchedule(as.Loans)})
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(all)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, al := range all {
+		fmt.Fprintln(tw, "Account: "+al.Account)
+		fmt.Fprintln(tw, "ID\tCurrency\tAmount\tRate (%/day)\tPeriod\tStart\tExpiry")
+		for _, l := range al.Loans {
+			fmt.Fprintln(tw, strconv.Itoa(l.ID)+"\t"+l.Currency+"\t"+
+				strconv.FormatFloat(l.Amount, 'f', -1, 64)+"\t"+
+				strconv.FormatFloat(l.Rate/365, 'f', 6, 64)+"\t"+
+				strconv.Itoa(l.Period)+"\t"+
+				l.Start.Format("2006-01-02 15:04")+"\t"+
+				l.Expiry.Format("2006-01-02 15:04"))
+		}
+
+		fmt.Fprintln(tw, "\nMaturity schedule:")
+		fmt.Fprintln(tw, "Currency\tDate\tAmount\tLoans\tRate (%/day)")
+		for _, cur := range sortedKeys(al.Schedule) {
+			for _, e := range al.Schedule[cur] {
+				fmt.Fprintln(tw, cur+"\t"+e.Date+"\t"+
+					strconv.FormatFloat(e.Amount, 'f', -1, 64)+"\t"+
+					strconv.Itoa(e.NumLoans)+"\t"+
+					strconv.FormatFloat(e.YearlyRate/365, 'f', 6, 64))
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// cmdApply executes previously saved plans after checking them against the current market.
+// Nothing is executed unless all plans pass the checks.
+func cmdApply(b *bot.Bot, args []string) (err error) {
+	if len(args) != 1 {
+		return errors.New("Usage: apply <planfile>")
+	}
+
+	plans, err := bot.ReadPlans(args[0])
+	if err != nil {
+		return
+	}
+
+	return b.ApplyPlans(plans, *tolerance, *dryRun)
+}
+
+// cmdServe keeps running the accounts on an interval and serves the control API
+func cmdServe(b *bot.Bot) (err error) {
+	if *listenAddr == "" && *runInterval <= 0 {
+		return errors.New("Nothing to serve: set --listen and/or --interval")
+	}
+
+	if *listenAddr != "" {
+		if *apiToken == "" {
+			return errors.New("Control API requires a bearer token (--apitoken or $BLB_API_TOKEN)")
+		}
+
+		log.Println("Serving dashboard and control API on http://" + *listenAddr + "/")
+
+		go func() {
+			log.Fatal(http.ListenAndServe(*listenAddr, bot.NewServeMux(b, *apiToken)))
+		}()
+	}
+
+	if *runInterval <= 0 {
+		// Only run on request
+		select {}
+	}
+
+	// Like the run command, serving always updates the lend offers unless --dryrun is set
+	return b.RunEvery(context.Background(), *runInterval, true, *dryRun)
+}
+
+func sortedKeys(schedule bot.MaturitySchedule) (keys []string) {
+	for k := range schedule {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
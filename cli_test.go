@@ -0,0 +1,48 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/config"
+)
+
+func TestCommandArgs(t *testing.T) {
+	defer func(j bool, a string) { *jsonOutput, *accountSel = j, a }(*jsonOutput, *accountSel)
+	*jsonOutput, *accountSel = false, ""
+
+	args, err := commandArgs([]string{"--json", "usd", "--account=acc1"})
+	if err != nil {
+		t.Fatal("Failed to parse arguments: " + err.Error())
+	}
+
+	if len(args) != 1 || args[0] != "usd" {
+		t.Error("Returned wrong positional arguments: " + strings.Join(args, ","))
+	}
+
+	if !*jsonOutput || *accountSel != "acc1" {
+		t.Error("Flags after the command were not applied")
+	}
+
+	if _, err := commandArgs([]string{"--nosuchflag"}); err == nil {
+		t.Error("Unknown flag accepted")
+	}
+}
+
+func TestSelectAccounts(t *testing.T) {
+	confs := config.Configs{config.Config{Name: "acc1"}, config.Config{Bitfinex: config.BitfinexConf{APIKey: "key2"}}}
+
+	if selected, _ := selectAccounts(confs, ""); len(selected) != 2 {
+		t.Error("Empty selection did not keep all accounts")
+	}
+
+	if selected, _ := selectAccounts(confs, "key2"); len(selected) != 1 || selected[0].Bitfinex.APIKey != "key2" {
+		t.Error("Failed to select account by API key")
+	}
+
+	if _, err := selectAccounts(confs, "nope"); err == nil {
+		t.Error("Unknown account accepted")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+// Package config reads and validates the account configuration
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Config ...
+type Config struct {
+	Name     string
+	Bitfinex BitfinexConf
+	Strategy strategy.Conf
+
+	Notifications notify.NotificationsConf
+	Guardrails    GuardrailsConf
+	Sweep         SweepConf
+	Reserves      map[string]ReserveConf // By currency
+	Schedule      ScheduleConf
+
+	API        *bitfinex.API
+	ExtAPI     *exchange.BitfinexExt
+	Currencies *exchange.CurrencyRegistry `json:"-"` // Funding limits of the exchange, defaults only if nil
+	Prices     *exchange.PriceOracle      `json:"-"`
+	Owned      *strategy.OwnedOffers      `json:"-"` // Offers created by each strategy, kept in the local state
+	Explain    bool
+	Log        *log.Logger `json:"-"` // Where the account's runs are logged, the standard logger if nil
+}
+
+// Configs ...
+type Configs []Config
+
+// BitfinexConf ...
+type BitfinexConf struct {
+	APIKey          string
+	APISecret       string
+	ActiveWallet    string
+	MaxActiveAmount float64
+	MinLoanUSD      float64
+	LendingFeePct   float64
+
+	// Currency metadata overrides, by currency
+	Currencies map[string]exchange.CurrencyInfo
+}
+
+// Load reads the accounts from the config file
+func Load(path string) (confs Configs, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("Failed to open config file: " + err.Error())
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(&confs)
+	if err != nil {
+		return nil, errors.New("Failed to parse config file: " + err.Error())
+	}
+
+	return
+}
+
+// AccountName identifies the account in the local state and command output
+func (c Config) AccountName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.Bitfinex.APIKey
+}
+
+// StrategyConfig returns what the account's strategies run with
+func (c Config) StrategyConfig() strategy.Config {
+	return strategy.Config{
+		Account:         c.AccountName(),
+		Strategy:        c.Strategy,
+		MaxActiveAmount: c.Bitfinex.MaxActiveAmount,
+		ExtAPI:          c.ExtAPI,
+		Owned:           c.Owned,
+		Explain:         c.Explain,
+		Log:             c.Log,
+	}
+}
+
+// Logger returns where the account's runs are logged
+func (c Config) Logger() *log.Logger {
+	if c.Log == nil {
+		return log.Default()
+	}
+
+	return c.Log
+}
+
+// CurrencyInfo returns the metadata of the currency for the account
+func (c Config) CurrencyInfo(currency string) exchange.CurrencyInfo {
+	return c.Currencies.Info(c.ExtAPI, currency, c.Bitfinex.Currencies, c.Logger())
+}
+
+// Price converts currency from to currency to using the shared price oracle
+func (c Config) Price(from, to string) (float64, error) {
+	o := c.Prices
+	if o == nil {
+		o = exchange.NewPriceOracle(nil, 0)
+	}
+
+	return o.Price(c.API.Ticker, from, to, c.Logger())
+}
+
+// ReserveConf keeps funds of a currency unlent and makes sure lent funds
+// return before the planned withdrawals
+type ReserveConf struct {
+	Amount float64 // Always kept unlent
+	Pct    float64 // Share of the wallet amount always kept unlent, %
+	Needs  []LiquidityNeed
+}
+
+// LiquidityNeed is an amount that must be in the wallet, unlent, on a date
+type LiquidityNeed struct {
+	Date   string // YYYY-MM-DD (UTC)
+	Amount float64
+}
+
+// Reserve returns the reserve settings of the currency
+func (c Config) Reserve(currency string) ReserveConf {
+	for cur, r := range c.Reserves {
+		if strings.ToLower(cur) == currency {
+			return r
+		}
+	}
+
+	return ReserveConf{}
+}
+
+// GuardrailsConf are hard limits that block a plan from being executed.
+// Zero values disable the limit.
+type GuardrailsConf struct {
+	MinDailyRate     float64 // Lowest allowed offer rate, %/day
+	MaxDailyRate     float64 // Highest allowed offer rate, %/day
+	MaxRateChangePct float64 // Largest change of the average offer rate vs the last executed plan, %
+	MaxOffers        int     // Most new offers per run
+	MaxAmount        float64 // Most funds offered per run
+}
+
+// Wallets funds can be swept from
+var sweepWallets = []string{"exchange", "trading"}
+
+// SweepConf moves idle funds of the active currency from other wallets
+// into the deposit wallet before the strategy runs. Disabled without Wallets.
+type SweepConf struct {
+	Wallets   []string // Source wallets: "exchange", "trading"
+	Floor     float64  // Amount left available in each source wallet
+	MinAmount float64  // Smaller transfers are not made
+	MaxAmount float64  // Most moved per run, 0 for no limit
+}
@@ -0,0 +1,276 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ScheduleConf overrides strategy settings at certain times, evaluated on every run
+type ScheduleConf struct {
+	Timezone string // IANA name, e.g. "America/New_York", defaults to UTC
+	Rules    []ScheduleRule
+}
+
+// ScheduleRule applies its Strategy overrides while all of its conditions match.
+// All matching rules are applied in order, so later rules win.
+type ScheduleRule struct {
+	Name     string
+	Cron     string   // "minute hour day-of-month month day-of-week"
+	Weekdays []string // "mon", "tue", ...
+	Hours    string   // "13-21", from hour inclusive to hour exclusive, may wrap around midnight
+
+	// Partial strategy configuration, e.g. {"CascadeBot": {"StartDailyLendRateFRRInc": 0.05}} or {"Active": "Harmonia"}
+	Strategy json.RawMessage
+}
+
+// cronField matches one field of a cron expression: "*", "5", "1-5", "*/15", "0-30/10", "1,3,5"
+func cronField(field string, value, min, max int) (bool, error) {
+	invalid := errors.New("Invalid cron field \"" + field + "\"")
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return false, invalid
+			}
+			step, part = s, part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+			from, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return false, invalid
+			}
+
+			to = from
+			if len(bounds) == 2 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return false, invalid
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return false, invalid
+		}
+
+		if value >= from && value <= to && (value-from)%step == 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// cronMatches checks a five field cron expression against the time
+func cronMatches(expr string, t time.Time) (bool, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return false, errors.New("Cron expression \"" + expr + "\" must have 5 fields")
+	}
+
+	weekday := int(t.Weekday())
+	values := []struct{ value, min, max int }{
+		{t.Minute(), 0, 59},
+		{t.Hour(), 0, 23},
+		{t.Day(), 1, 31},
+		{int(t.Month()), 1, 12},
+		{weekday, 0, 7},
+	}
+
+	matches := make([]bool, len(fields))
+	for i, f := range fields {
+		ok, err := cronField(f, values[i].value, values[i].min, values[i].max)
+		if err != nil {
+			return false, err
+		}
+
+		// Sunday is both 0 and 7
+		if !ok && i == 4 && weekday == 0 {
+			ok, _ = cronField(f, 7, 0, 7)
+		}
+
+		matches[i] = ok
+	}
+
+	// As in cron, if both day fields are restricted either of them matching is enough
+	day := matches[2] && matches[4]
+	if !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*") {
+		day = matches[2] || matches[4]
+	}
+
+	return matches[0] && matches[1] && matches[3] && day, nil
+}
+
+// hoursMatch checks an "from-to" hour window against the hour
+func hoursMatch(window string, hour int) (bool, error) {
+	bounds := strings.SplitN(window, "-", 2)
+	if len(bounds) != 2 {
+		return false, errors.New("Invalid hour window \"" + window + "\"")
+	}
+
+	from, err1 := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	to, err2 := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	if err1 != nil || err2 != nil || from < 0 || from > 23 || to < 0 || to > 24 {
+		return false, errors.New("Invalid hour window \"" + window + "\"")
+	}
+
+	if from <= to {
+		return hour >= from && hour < to, nil
+	}
+
+	// Wraps around midnight
+	return hour >= from || hour < to, nil
+}
+
+// matches checks every condition of the rule against the local time
+func (r ScheduleRule) matches(t time.Time) (bool, error) {
+	if r.Cron != "" {
+		ok, err := cronMatches(r.Cron, t)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if len(r.Weekdays) > 0 {
+		found := false
+		for _, d := range r.Weekdays {
+			d = strings.ToLower(d)
+			if !containsString(weekdayNames, d) {
+				return false, errors.New("Invalid weekday \"" + d + "\"")
+			}
+
+			found = found || d == weekdayNames[t.Weekday()]
+		}
+
+		if !found {
+			return false, nil
+		}
+	}
+
+	if r.Hours != "" {
+		return hoursMatch(r.Hours, t.Hour())
+	}
+
+	return true, nil
+}
+
+// check parses every condition of the rule, whatever the current time
+func (r ScheduleRule) check() (problems []string) {
+	if r.Cron != "" {
+		fields := strings.Fields(r.Cron)
+		if len(fields) != 5 {
+			problems = append(problems, "Cron expression \""+r.Cron+"\" must have 5 fields")
+		} else {
+			bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+			for i, f := range fields {
+				// No value is below the minimum, so every part of the field is parsed
+				if _, err := cronField(f, bounds[i][0]-1, bounds[i][0], bounds[i][1]); err != nil {
+					problems = append(problems, err.Error())
+				}
+			}
+		}
+	}
+
+	for _, d := range r.Weekdays {
+		if !containsString(weekdayNames, strings.ToLower(d)) {
+			problems = append(problems, "Invalid weekday \""+strings.ToLower(d)+"\"")
+		}
+	}
+
+	if r.Hours != "" {
+		if _, err := hoursMatch(r.Hours, 0); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	return
+}
+
+// location returns the schedule's timezone
+func (c ScheduleConf) location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, errors.New("Invalid schedule timezone \"" + c.Timezone + "\": " + err.Error())
+	}
+
+	return loc, nil
+}
+
+// Scheduled returns the configuration with the strategy overrides of the rules matching now applied,
+// and the names of those rules
+func (c Config) Scheduled(now time.Time) (conf Config, active []string, err error) {
+	conf = c
+	if len(c.Schedule.Rules) == 0 {
+		return
+	}
+
+	loc, err := c.Schedule.location()
+	if err != nil {
+		return
+	}
+
+	// Deep copy, so that decoding into slices does not change the account's settings
+	data, err := json.Marshal(c.Strategy)
+	if err != nil {
+		return
+	}
+	conf.Strategy = strategy.Conf{}
+	err = json.Unmarshal(data, &conf.Strategy)
+	if err != nil {
+		return
+	}
+
+	local := now.In(loc)
+	for i, r := range c.Schedule.Rules {
+		name := r.Name
+		if name == "" {
+			name = "#" + strconv.Itoa(i)
+		}
+
+		ok, err := r.matches(local)
+		if err != nil {
+			return c, nil, errors.New("Schedule rule " + name + ": " + err.Error())
+		}
+		if !ok {
+			continue
+		}
+
+		// Decoding over the current settings only changes the fields present in the rule
+		if len(r.Strategy) > 0 {
+			err = json.Unmarshal(r.Strategy, &conf.Strategy)
+			if err != nil {
+				return c, nil, errors.New("Schedule rule " + name + ": invalid Strategy: " + err.Error())
+			}
+		}
+
+		active = append(active, name)
+	}
+
+	if len(active) > 0 {
+		c.Logger().Println("\tSchedule rules active: " + strings.Join(active, ", "))
+	}
+
+	return
+}
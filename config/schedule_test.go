@@ -0,0 +1,191 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package config
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronMatches(t *testing.T) {
+	// Monday
+	at := time.Date(2017, 5, 15, 14, 30, 0, 0, time.UTC)
+	sunday := time.Date(2017, 5, 14, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr  string
+		t     time.Time
+		match bool
+	}{
+		{"* * * * *", at, true},
+		{"30 14 * * *", at, true},
+		{"31 14 * * *", at, false},
+		{"*/15 * * * *", at, true},
+		{"*/20 * * * *", at, false},
+		{"0-30/10 * * * *", at, true},
+		{"* 9-17 * * 1-5", at, true},
+		{"* 9-13 * * 1-5", at, false},
+		{"* * 15 5 *", at, true},
+		{"* * * 1,3,6 *", at, false},
+		{"* * * * 1,3", at, true},
+		{"* * * * 0", sunday, true},
+		{"* * * * 7", sunday, true},
+		{"* * * * 6-7", sunday, true},
+		{"* * * * 1-5", sunday, false},
+		{"* * 1 * 1", at, true}, // Day of month or day of week
+		{"* * 15 * 0", at, true},
+		{"* * 1 * 0", at, false},
+		{"* * */2 * 1", at, true}, // Steps over all days do not restrict the day
+		{"* * */2 * 2", at, false},
+		{"* * 1 * 1", time.Date(2017, 6, 1, 14, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		match, err := cronMatches(tt.expr, tt.t)
+		if err != nil {
+			t.Error("Failed to match \"" + tt.expr + "\": " + err.Error())
+			continue
+		}
+
+		if match != tt.match {
+			t.Error("Returned wrong match for \"" + tt.expr + "\" (" + strconv.FormatBool(match) +
+				", expected: " + strconv.FormatBool(tt.match) + ")")
+		}
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := cronMatches(expr, at); err == nil {
+			t.Error("Invalid cron expression \"" + expr + "\" accepted")
+		}
+	}
+}
+
+func TestHoursMatch(t *testing.T) {
+	tests := []struct {
+		window string
+		hour   int
+		match  bool
+	}{
+		{"9-17", 9, true},
+		{"9-17", 16, true},
+		{"9-17", 17, false},
+		{"0-24", 23, true},
+		{"22-6", 23, true},
+		{"22-6", 3, true},
+		{"22-6", 6, false},
+		{"22-6", 12, false},
+	}
+
+	for _, tt := range tests {
+		match, err := hoursMatch(tt.window, tt.hour)
+		if err != nil || match != tt.match {
+			t.Error("Returned wrong match for \"" + tt.window + "\" at " + strconv.Itoa(tt.hour) + " (" + strconv.FormatBool(match) +
+				", expected: " + strconv.FormatBool(tt.match) + ")")
+		}
+	}
+
+	for _, window := range []string{"9", "9-25", "24-1", "a-b"} {
+		if _, err := hoursMatch(window, 0); err == nil {
+			t.Error("Invalid hour window \"" + window + "\" accepted")
+		}
+	}
+}
+
+func TestScheduleRule_Matches(t *testing.T) {
+	// Monday
+	at := time.Date(2017, 5, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule  ScheduleRule
+		match bool
+	}{
+		{ScheduleRule{}, true},
+		{ScheduleRule{Weekdays: []string{"Mon", "tue"}}, true},
+		{ScheduleRule{Weekdays: []string{"sat", "sun"}}, false},
+		{ScheduleRule{Weekdays: []string{"mon"}, Hours: "9-14"}, false},
+		{ScheduleRule{Weekdays: []string{"mon"}, Hours: "9-15"}, true},
+		{ScheduleRule{Cron: "* * * * 1", Hours: "15-9"}, false},
+	}
+
+	for i, tt := range tests {
+		match, err := tt.rule.matches(at)
+		if err != nil || match != tt.match {
+			t.Error("Case " + strconv.Itoa(i) + ": returned wrong match (" + strconv.FormatBool(match) +
+				", expected: " + strconv.FormatBool(tt.match) + ")")
+		}
+	}
+
+	if _, err := (ScheduleRule{Weekdays: []string{"monday"}}).matches(at); err == nil {
+		t.Error("Invalid weekday accepted")
+	}
+}
+
+func TestConfig_Scheduled(t *testing.T) {
+	var conf Config
+	err := json.Unmarshal([]byte(`{
+		"Strategy": {
+			"Active": "CascadeBot",
+			"CascadeBot": {"StartDailyLendRateFRRInc": 0.01, "LendPeriod": 2},
+			"MarginBot": {"MinDailyLendRate": 0.01, "SpreadLend": 3, "PeriodCurve": {"Points": [{"DailyRate": 0.05, "Period": 2}, {"DailyRate": 0.1, "Period": 30}]}}
+		},
+		"Schedule": {
+			"Timezone": "America/New_York",
+			"Rules": [
+				{"Name": "us-hours", "Weekdays": ["mon", "tue", "wed", "thu", "fri"], "Hours": "9-16",
+					"Strategy": {"CascadeBot": {"StartDailyLendRateFRRInc": 0.05}}},
+				{"Name": "afternoon", "Hours": "12-16",
+					"Strategy": {"Active": "MarginBot", "MarginBot": {"PeriodCurve": {"Points": [{"DailyRate": 0.2, "Period": 60}]}}}},
+				{"Name": "night", "Hours": "22-6", "Strategy": {"CascadeBot": {"LendPeriod": 30}}}
+			]
+		}
+	}`), &conf)
+	if err != nil {
+		t.Fatal("Failed to parse the config: " + err.Error())
+	}
+
+	// 10:00 in New York on a Monday
+	sconf, active, err := conf.Scheduled(time.Date(2017, 5, 15, 14, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal("Failed to apply the schedule: " + err.Error())
+	}
+
+	if strings.Join(active, ",") != "us-hours" {
+		t.Error("Returned wrong active rules (" + strings.Join(active, ",") + ", expected: us-hours)")
+	}
+	if sconf.Strategy.CascadeBot.StartDailyLendRateFRRInc != 0.05 || sconf.Strategy.CascadeBot.LendPeriod != 2 {
+		t.Error("Override not applied to the CascadeBot settings")
+	}
+	if sconf.Strategy.Active != "CascadeBot" {
+		t.Error("Active strategy changed (" + sconf.Strategy.Active + ", expected: CascadeBot)")
+	}
+
+	// 13:00 in New York on a Monday, later rules win
+	sconf, active, _ = conf.Scheduled(time.Date(2017, 5, 15, 17, 0, 0, 0, time.UTC))
+	if strings.Join(active, ",") != "us-hours,afternoon" {
+		t.Error("Returned wrong active rules (" + strings.Join(active, ",") + ", expected: us-hours,afternoon)")
+	}
+	if sconf.Strategy.Active != "MarginBot" || len(sconf.Strategy.MarginBot.PeriodCurve.Points) != 1 ||
+		sconf.Strategy.MarginBot.SpreadLend != 3 {
+		t.Error("Strategy switch not applied")
+	}
+
+	// The account's settings stay unchanged
+	if conf.Strategy.CascadeBot.StartDailyLendRateFRRInc != 0.01 || conf.Strategy.Active != "CascadeBot" ||
+		len(conf.Strategy.MarginBot.PeriodCurve.Points) != 2 || conf.Strategy.MarginBot.PeriodCurve.Points[0].Period != 2 {
+		t.Error("Schedule changed the account's settings")
+	}
+
+	// 23:00 in New York on a Saturday
+	sconf, active, _ = conf.Scheduled(time.Date(2017, 5, 21, 3, 0, 0, 0, time.UTC))
+	if strings.Join(active, ",") != "night" || sconf.Strategy.CascadeBot.LendPeriod != 30 {
+		t.Error("Returned wrong active rules (" + strings.Join(active, ",") + ", expected: night)")
+	}
+
+	conf.Schedule.Timezone = "Nowhere/Nothing"
+	if _, _, err := conf.Scheduled(time.Now()); err == nil {
+		t.Error("Invalid timezone accepted")
+	}
+}
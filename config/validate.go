@@ -0,0 +1,281 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package config
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+var knownMetrics = []string{notify.MetricIdleFunds, notify.MetricIdleValue, notify.MetricFRR, notify.MetricFailedRuns, notify.MetricUtilization,
+	notify.MetricMinDailyRate, notify.MetricStartDailyRate, notify.MetricHighHoldMargin}
+
+// Validate checks the account configuration without contacting the exchange
+// and returns a description of every problem found
+func (c Config) Validate() (problems []string) {
+	add := func(p string) {
+		problems = append(problems, p)
+	}
+	ftoa := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	if c.Bitfinex.APIKey == "" || c.Bitfinex.APISecret == "" {
+		add("Bitfinex.APIKey and Bitfinex.APISecret are required")
+	}
+	if c.Bitfinex.ActiveWallet == "" {
+		add("Bitfinex.ActiveWallet is required")
+	}
+	if c.Bitfinex.MinLoanUSD < 0 {
+		add("Bitfinex.MinLoanUSD must not be negative")
+	}
+	if c.Bitfinex.LendingFeePct < 0 || c.Bitfinex.LendingFeePct >= 100 {
+		add("Bitfinex.LendingFeePct must be in [0, 100)")
+	}
+
+	var currencies []string
+	for cur := range c.Bitfinex.Currencies {
+		currencies = append(currencies, cur)
+	}
+	sort.Strings(currencies)
+
+	lower := map[string]string{}
+	for _, cur := range currencies {
+		info := c.Bitfinex.Currencies[cur]
+		prefix := "Bitfinex.Currencies." + cur
+		if other, ok := lower[strings.ToLower(cur)]; ok {
+			add(prefix + " duplicates Bitfinex.Currencies." + other + ", currencies are case-insensitive")
+		}
+		lower[strings.ToLower(cur)] = cur
+		if info.MinOfferSize < 0 || info.MaxDailyRate < 0 || info.MinPeriod < 0 || info.MaxPeriod < 0 {
+			add(prefix + " values must not be negative")
+		}
+		if info.AmountPlaces < 0 || info.AmountPlaces > exchange.DecimalPlaces {
+			add(prefix + ".AmountPlaces must be in [0, " + strconv.Itoa(exchange.DecimalPlaces) + "]")
+		}
+		if info.MinPeriod > 0 && info.MaxPeriod > 0 && info.MinPeriod > info.MaxPeriod {
+			add(prefix + ".MinPeriod (" + strconv.Itoa(info.MinPeriod) + ") is higher than MaxPeriod (" + strconv.Itoa(info.MaxPeriod) + ")")
+		}
+	}
+
+	// Settings of every strategy in use
+	strategies, fields := []string{c.Strategy.Active}, []string{"Strategy.Active"}
+	if len(c.Strategy.Allocations) > 0 {
+		strategies, fields = nil, nil
+
+		pct, seen := 0.0, map[string]bool{}
+		for i, a := range c.Strategy.Allocations {
+			field := "Strategy.Allocations[" + strconv.Itoa(i) + "]"
+			if seen[strings.ToLower(a.Strategy)] {
+				add(field + ": " + a.Strategy + " is allocated more than once")
+				continue
+			}
+			if a.Pct < 0 || a.Pct > 100 || a.Amount < 0 {
+				add(field + ": Pct must be in [0, 100] and Amount must not be negative")
+			}
+			if a.Pct == 0 && a.Amount == 0 {
+				add(field + ": either Pct or Amount is required")
+			}
+			if a.Amount == 0 {
+				pct += a.Pct
+			}
+
+			seen[strings.ToLower(a.Strategy)] = true
+			strategies = append(strategies, a.Strategy)
+			fields = append(fields, field+".Strategy")
+		}
+
+		if pct > 100 {
+			add("Strategy.Allocations add up to " + ftoa(pct) + "%")
+		}
+	}
+
+	for i, name := range strategies {
+		field := fields[i]
+		switch strings.ToLower(name) {
+		case "marginbot":
+			mb := c.Strategy.MarginBot
+			if mb.MinDailyLendRate <= 0 {
+				add("MarginBot.MinDailyLendRate must be positive")
+			}
+			if mb.SpreadLend < 1 {
+				add("MarginBot.SpreadLend must be at least 1")
+			}
+			if mb.GapTop < mb.GapBottom {
+				add("MarginBot.GapTop (" + ftoa(mb.GapTop) + ") is lower than GapBottom (" + ftoa(mb.GapBottom) + ")")
+			}
+			problems = append(problems, mb.PeriodCurve.Validate("MarginBot.PeriodCurve")...)
+		case "cascadebot":
+			cb := c.Strategy.CascadeBot
+			if cb.MinDailyLendRate <= 0 {
+				add("CascadeBot.MinDailyLendRate must be positive")
+			}
+			if cb.ReductionIntervalMinutes <= 0 {
+				add("CascadeBot.ReductionIntervalMinutes must be positive")
+			}
+			if cb.ExponentialDecayMult <= 0 || cb.ExponentialDecayMult > 1 {
+				add("CascadeBot.ExponentialDecayMult must be in (0, 1]")
+			}
+			if cb.LendPeriod < exchange.MinLendPeriod || cb.LendPeriod > exchange.MaxLendPeriod {
+				add("CascadeBot.LendPeriod must be in [" + strconv.Itoa(exchange.MinLendPeriod) + ", " + strconv.Itoa(exchange.MaxLendPeriod) + "] days")
+			}
+			problems = append(problems, cb.PeriodCurve.Validate("CascadeBot.PeriodCurve")...)
+		case "harmonia":
+			h := c.Strategy.Harmonia
+			if h.MinDailyLendRate <= 0 {
+				add("Harmonia.MinDailyLendRate must be positive")
+			}
+			if h.SpreadLend < 1 {
+				add("Harmonia.SpreadLend must be at least 1")
+			}
+			if h.DepthPctBottom < 0 || h.DepthPctTop > 100 || h.DepthPctBottom > h.DepthPctTop {
+				add("Harmonia depth range [" + ftoa(h.DepthPctBottom) + "%, " + ftoa(h.DepthPctTop) + "%] is invalid")
+			}
+			problems = append(problems, h.PeriodCurve.Validate("Harmonia.PeriodCurve")...)
+		case "script":
+			s := c.Strategy.Script
+			if s.Rate.Empty() {
+				add("Script.Rate is required")
+			}
+			if err := s.Check(); err != nil {
+				add(err.Error())
+			}
+		case "plugin":
+			p := c.Strategy.Plugin
+			if p.Command == "" {
+				add("Plugin.Command is required")
+			}
+			if p.TimeoutSeconds < 0 {
+				add("Plugin.TimeoutSeconds must not be negative")
+			}
+			if err := p.Check(); err != nil {
+				add(err.Error())
+			}
+			for _, e := range p.Env {
+				if !strings.Contains(e, "=") {
+					add("Plugin.Env \"" + e + "\" is not NAME=value")
+				}
+			}
+		default:
+			add("Unknown " + field + ": \"" + name + "\"")
+		}
+	}
+
+	g := c.Guardrails
+	if g.MinDailyRate < 0 || g.MaxDailyRate < 0 || g.MaxRateChangePct < 0 || g.MaxOffers < 0 || g.MaxAmount < 0 {
+		add("Guardrails limits must not be negative")
+	}
+	if g.MaxDailyRate > 0 && g.MinDailyRate > g.MaxDailyRate {
+		add("Guardrails.MinDailyRate (" + ftoa(g.MinDailyRate) + ") is higher than MaxDailyRate (" + ftoa(g.MaxDailyRate) + ")")
+	}
+
+	for _, w := range c.Sweep.Wallets {
+		if !containsString(sweepWallets, strings.ToLower(w)) {
+			add("Sweep.Wallets: unknown wallet \"" + w + "\"")
+		}
+	}
+	if c.Sweep.Floor < 0 || c.Sweep.MinAmount < 0 || c.Sweep.MaxAmount < 0 {
+		add("Sweep limits must not be negative")
+	}
+
+	var reserveCurrencies []string
+	for cur := range c.Reserves {
+		reserveCurrencies = append(reserveCurrencies, cur)
+	}
+	sort.Strings(reserveCurrencies)
+
+	for _, cur := range reserveCurrencies {
+		r := c.Reserves[cur]
+		prefix := "Reserves." + cur
+		if r.Amount < 0 || r.Pct < 0 || r.Pct > 100 {
+			add(prefix + ": Amount must not be negative and Pct must be in [0, 100]")
+		}
+		for i, n := range r.Needs {
+			if _, err := time.Parse("2006-01-02", n.Date); err != nil {
+				add(prefix + ".Needs[" + strconv.Itoa(i) + "].Date must be YYYY-MM-DD")
+			}
+			if n.Amount <= 0 {
+				add(prefix + ".Needs[" + strconv.Itoa(i) + "].Amount must be positive")
+			}
+		}
+	}
+
+	if _, err := c.Schedule.location(); err != nil {
+		add(err.Error())
+	}
+	for i, r := range c.Schedule.Rules {
+		prefix := "Schedule.Rules[" + strconv.Itoa(i) + "]: "
+		if r.Cron == "" && len(r.Weekdays) == 0 && r.Hours == "" {
+			add(prefix + "no Cron, Weekdays or Hours condition")
+		}
+		for _, p := range r.check() {
+			add(prefix + p)
+		}
+
+		// Decoded into empty settings, so that the account's slices are left alone
+		var sc strategy.Conf
+		if err := json.Unmarshal(r.Strategy, &sc); len(r.Strategy) > 0 && err != nil {
+			add(prefix + "invalid Strategy: " + err.Error())
+		}
+	}
+
+	nc := c.Notifications
+	for i, wh := range nc.Webhooks {
+		if wh.URL == "" {
+			add("Notifications.Webhooks[" + strconv.Itoa(i) + "].URL is required")
+		}
+	}
+
+	if nc.Email.Host != "" && (nc.Email.From == "" || len(nc.Email.To) == 0) {
+		add("Notifications.Email requires From and To")
+	}
+
+	sinks := map[string]bool{}
+	for _, n := range nc.Notifiers() {
+		sinks[n.ID()] = true
+	}
+
+	for _, r := range nc.AlertRules() {
+		prefix := "Alert rule \"" + r.Name + "\": "
+		if r.Name == "" {
+			add("Alert rule without Name")
+		}
+
+		// A disabled rule, e.g. one switching off a built-in check, needs only its Name
+		if !r.Disabled {
+			if !containsString(knownMetrics, r.Metric) {
+				add(prefix + "unknown metric \"" + r.Metric + "\"")
+			}
+			if !containsString([]string{">", ">=", "<", "<=", "==", "!="}, r.Op) {
+				add(prefix + "unknown operator \"" + r.Op + "\"")
+			}
+			if r.Severity != "" && !containsString([]string{notify.SeverityInfo, notify.SeverityWarning, notify.SeverityCritical}, r.Severity) {
+				add(prefix + "unknown severity \"" + r.Severity + "\"")
+			}
+		}
+		for _, s := range r.Sinks {
+			if !sinks[s] {
+				add(prefix + "unknown sink \"" + s + "\"")
+			}
+		}
+	}
+
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/BitfinexLendingBot/notify"
+	"github.com/eAndrius/BitfinexLendingBot/strategy"
+)
+
+func TestValidate_DefaultConf(t *testing.T) {
+	f, err := os.Open("../default.conf")
+	if err != nil {
+		t.Fatal("Failed to open default.conf: " + err.Error())
+	}
+	defer f.Close()
+
+	confs := Configs{}
+	if err := json.NewDecoder(f).Decode(&confs); err != nil {
+		t.Fatal("Failed to parse default.conf: " + err.Error())
+	}
+
+	for _, c := range confs {
+		if problems := c.Validate(); len(problems) != 0 {
+			t.Error("Example configuration " + c.AccountName() + " is invalid: " + strings.Join(problems, "; "))
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Config{
+		Bitfinex: BitfinexConf{APIKey: "key", APISecret: "secret", ActiveWallet: "usd", MinLoanUSD: 50,
+			Currencies: map[string]exchange.CurrencyInfo{"btc": {AmountPlaces: 9, MinPeriod: 30, MaxPeriod: 2}, "BTC": {}}},
+		Strategy: strategy.Conf{Active: "Harmonia", Harmonia: strategy.HarmoniaConf{MinDailyLendRate: 0.01, SpreadLend: 3,
+			DepthPctBottom: 50, DepthPctTop: 10, PeriodCurve: strategy.PeriodCurveConf{Points: []strategy.PeriodCurvePoint{{DailyRate: 0.1, Period: 60}}}}},
+		Notifications: notify.NotificationsConf{
+			Webhooks: []notify.WebhookConf{{Name: "chat", URL: "http://localhost"}},
+			Rules: []notify.AlertRuleConf{{Name: "r", Metric: "nope", Op: "~", Sinks: []string{"chat", "pager"}},
+				{Name: "low_min_daily_rate", Disabled: true}},
+		},
+	}
+
+	problems := strings.Join(c.Validate(), "\n")
+	for _, p := range []string{"depth range", "Points[0].Period", "unknown metric", "unknown operator", "unknown sink \"pager\"",
+		"Currencies.btc.AmountPlaces", "Currencies.btc.MinPeriod", "Currencies.btc duplicates Bitfinex.Currencies.BTC"} {
+		if !strings.Contains(problems, p) {
+			t.Error("Problem \"" + p + "\" not reported in:\n" + problems)
+		}
+	}
+
+	if strings.Contains(problems, "\"chat\"") {
+		t.Error("Configured sink reported as unknown")
+	}
+
+	if strings.Contains(problems, "low_min_daily_rate") {
+		t.Error("Disabled rule reported in:\n" + problems)
+	}
+
+	c.Strategy.Active = "Plugin"
+	c.Strategy.Plugin = strategy.PluginConf{Command: "/usr/local/bin/ladder"}
+	if problems := strings.Join(c.Validate(), "\n"); !strings.Contains(problems, "Plugin requires a Sandbox") {
+		t.Error("Plugin without a sandbox not reported")
+	}
+
+	c.Strategy.Active = "nope"
+	if problems := strings.Join(c.Validate(), "\n"); !strings.Contains(problems, "Unknown Strategy.Active") {
+		t.Error("Unknown strategy not reported")
+	}
+}
+
+func TestValidate_Schedule(t *testing.T) {
+	points := []strategy.PeriodCurvePoint{{DailyRate: 0.1, Period: 60}}
+	c := Config{
+		Bitfinex: BitfinexConf{APIKey: "key", APISecret: "secret", ActiveWallet: "usd"},
+		Strategy: strategy.Conf{Active: "MarginBot", MarginBot: strategy.MarginBotConf{MinDailyLendRate: 0.01, SpreadLend: 1,
+			PeriodCurve: strategy.PeriodCurveConf{Points: points}}},
+		Schedule: ScheduleConf{Rules: []ScheduleRule{
+			// Never matches, the later conditions must be checked anyway
+			{Cron: "0 0 31 2 *", Weekdays: []string{"funday"}, Hours: "25-3"},
+			{Cron: "0 0 31 2 1,x"},
+			{Hours: "9-17", Strategy: json.RawMessage(`{"MarginBot": {"PeriodCurve": {"Points": [{"DailyRate": 0.5, "Period": 2}]}}}`)},
+		}},
+	}
+
+	problems := strings.Join(c.Validate(), "\n")
+	for _, p := range []string{"Rules[0]: Invalid weekday \"funday\"", "Rules[0]: Invalid hour window \"25-3\"", "Rules[1]: Invalid cron field \"1,x\""} {
+		if !strings.Contains(problems, p) {
+			t.Error("Problem \"" + p + "\" not reported in:\n" + problems)
+		}
+	}
+
+	if strings.Contains(problems, "Rules[2]") {
+		t.Error("Valid rule reported in:\n" + problems)
+	}
+
+	if points[0].DailyRate != 0.1 || points[0].Period != 60 {
+		t.Error("Validation changed the account's PeriodCurve")
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Minimal Bitfinex REST client for the endpoints not covered by bitfinex-go.
+
+// Package exchange covers what the bot needs from Bitfinex besides the bitfinex-go client:
+// extra API calls, market data checks, currency metadata, prices and exact amounts
+package exchange
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIURL is the Bitfinex API endpoint, replaced by a local server in tests
+var APIURL = "https://api.bitfinex.com/v1/"
+
+// APIV2URL is the public Bitfinex v2 API endpoint, for the data v1 does not cover
+var APIV2URL = "https://api-pub.bitfinex.com/v2/"
+
+// BitfinexExt ...
+type BitfinexExt struct {
+	APIKey, APISecret string
+
+	client    *http.Client
+	nonceLock sync.Mutex
+	lastNonce int64
+}
+
+// Credit ...
+type Credit struct {
+	ID        int     `json:"id"`
+	Currency  string  `json:"currency"`
+	Status    string  `json:"status"`
+	Rate      float64 `json:"rate,string"`
+	Period    int     `json:"period"`
+	Amount    float64 `json:"amount,string"`
+	Timestamp float64 `json:"timestamp,string"`
+}
+
+// Credits ...
+type Credits []Credit
+
+// LedgerEntry ...
+type LedgerEntry struct {
+	Currency    string  `json:"currency"`
+	Amount      float64 `json:"amount,string"`
+	Balance     float64 `json:"balance,string"`
+	Description string  `json:"description"`
+	Timestamp   float64 `json:"timestamp,string"`
+}
+
+// LedgerEntries ...
+type LedgerEntries []LedgerEntry
+
+// NewBitfinexExt ...
+func NewBitfinexExt(key, secret string) *BitfinexExt {
+	return &BitfinexExt{
+		APIKey:    key,
+		APISecret: secret,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ActiveCredits returns funds currently lent out
+func (api *BitfinexExt) ActiveCredits() (credits Credits, err error) {
+	err = api.post("credits", nil, &credits)
+	return
+}
+
+// History returns up to limit deposit wallet ledger entries for the currency between since and until, newest first.
+// A zero until means now.
+func (api *BitfinexExt) History(currency string, since, until time.Time, limit int) (entries LedgerEntries, err error) {
+	params := map[string]interface{}{
+		"currency": strings.ToUpper(currency),
+		"since":    strconv.FormatInt(since.Unix(), 10),
+		"limit":    limit,
+		"wallet":   "deposit",
+	}
+	if !until.IsZero() {
+		params["until"] = strconv.FormatInt(until.Unix(), 10)
+	}
+
+	err = api.post("history", params, &entries)
+	return
+}
+
+func (api *BitfinexExt) nonce() string {
+	api.nonceLock.Lock()
+	defer api.nonceLock.Unlock()
+
+	// Nonce must be strictly increasing
+	n := time.Now().UnixNano()
+	if n <= api.lastNonce {
+		n = api.lastNonce + 1
+	}
+	api.lastNonce = n
+
+	return strconv.FormatInt(n, 10)
+}
+
+func (api *BitfinexExt) get(path string, result interface{}) (err error) {
+	req, err := http.NewRequest("GET", APIURL+path, nil)
+	if err != nil {
+		return
+	}
+
+	return api.do(req, result)
+}
+
+func (api *BitfinexExt) getV2(path string, result interface{}) (err error) {
+	req, err := http.NewRequest("GET", APIV2URL+path, nil)
+	if err != nil {
+		return
+	}
+
+	return api.do(req, result)
+}
+
+func (api *BitfinexExt) post(path string, params map[string]interface{}, result interface{}) (err error) {
+	payload := map[string]interface{}{
+		"request": "/v1/" + path,
+		"nonce":   api.nonce(),
+	}
+	for k, v := range params {
+		payload[k] = v
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	payloadEnc := base64.StdEncoding.EncodeToString(payloadJSON)
+
+	sig := hmac.New(sha512.New384, []byte(api.APISecret))
+	sig.Write([]byte(payloadEnc))
+
+	req, err := http.NewRequest("POST", APIURL+path, nil)
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("X-BFX-APIKEY", api.APIKey)
+	req.Header.Set("X-BFX-PAYLOAD", payloadEnc)
+	req.Header.Set("X-BFX-SIGNATURE", hex.EncodeToString(sig.Sum(nil)))
+
+	return api.do(req, result)
+}
+
+func (api *BitfinexExt) do(req *http.Request, result interface{}) (err error) {
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return errors.New("Bitfinex API error: " + apiErr.Message)
+		}
+
+		return errors.New("Bitfinex API error: " + resp.Status)
+	}
+
+	if result == nil {
+		return
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// Transfer moves funds between the account's wallets
+func (api *BitfinexExt) Transfer(currency string, amount Decimal, from, to string) (err error) {
+	var result []struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+
+	err = api.post("transfer", map[string]interface{}{
+		"amount":     amount.String(),
+		"currency":   strings.ToUpper(currency),
+		"walletfrom": from,
+		"walletto":   to,
+	}, &result)
+	if err != nil {
+		return
+	}
+
+	if len(result) == 0 || result[0].Status != "success" {
+		msg := "no response"
+		if len(result) > 0 {
+			msg = result[0].Message
+		}
+
+		return errors.New("Transfer failed: " + msg)
+	}
+
+	return
+}
+
+// LendRate is a point of the exchange's lend rate history
+type LendRate struct {
+	Rate       float64 `json:"rate,string"` // %/year
+	AmountLent float64 `json:"amount_lent,string"`
+	Timestamp  float64 `json:"timestamp"`
+}
+
+// Lends returns the most recent lend rates of the currency, newest first
+func (api *BitfinexExt) Lends(currency string, limit int) (lends []LendRate, err error) {
+	err = api.get("lends/"+strings.ToLower(currency)+"?limit_lends="+strconv.Itoa(limit), &lends)
+	return
+}
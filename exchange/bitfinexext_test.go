@@ -0,0 +1,57 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBitfinexExt_SignedRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payloadEnc := r.Header.Get("X-BFX-PAYLOAD")
+
+		sig := hmac.New(sha512.New384, []byte("secret"))
+		sig.Write([]byte(payloadEnc))
+		if r.Header.Get("X-BFX-APIKEY") != "key" || r.Header.Get("X-BFX-SIGNATURE") != hex.EncodeToString(sig.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Invalid signature"}`))
+			return
+		}
+
+		payloadJSON, _ := base64.StdEncoding.DecodeString(payloadEnc)
+		payload := map[string]interface{}{}
+		json.Unmarshal(payloadJSON, &payload)
+		if payload["request"] != "/v1/credits" || payload["nonce"] == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Write([]byte(`[{"id":42,"currency":"BTC","status":"ACTIVE","rate":"36.5","period":2,"amount":"1.5","timestamp":"1444277602.0"}]`))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { APIURL = url }(APIURL)
+	APIURL = srv.URL + "/v1/"
+
+	credits, err := NewBitfinexExt("key", "secret").ActiveCredits()
+	if err != nil {
+		t.Fatal("Request failed: " + err.Error())
+	}
+
+	if len(credits) != 1 || credits[0].ID != 42 || credits[0].Amount != 1.5 || credits[0].Rate != 36.5 {
+		t.Errorf("Returned wrong credits %v", credits)
+	}
+
+	// Bad credentials are reported with the API message
+	_, err = NewBitfinexExt("key", "wrong").ActiveCredits()
+	if err == nil || err.Error() != "Bitfinex API error: Invalid signature" {
+		t.Errorf("Returned wrong error (%v, expected: invalid signature)", err)
+	}
+}
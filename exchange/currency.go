@@ -0,0 +1,215 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long exchange currency metadata is used before it is fetched again
+const currencyCacheTTL = 24 * time.Hour
+
+// CurrencyInfo describes the funding offers the exchange accepts in a currency.
+// In config overrides zero values keep the exchange (or default) value.
+type CurrencyInfo struct {
+	MinOfferSize float64 // Smallest offer amount, in the currency
+	AmountPlaces int     // Decimal places of offer amounts
+	MinPeriod    int     // Days
+	MaxPeriod    int     // Days
+	MaxDailyRate float64 // Highest offer rate, %/day
+}
+
+// CurrencyRegistry is the funding metadata of the exchange, cached in a local file
+type CurrencyRegistry struct {
+	Updated    time.Time
+	Currencies map[string]CurrencyInfo
+
+	path string
+	lock sync.Mutex
+}
+
+// FundingInfo returns the funding offer limits of every currency from the exchange's funding config:
+// [[[CURRENCY, [MIN_OFFER_SIZE, AMOUNT_PLACES, MIN_PERIOD, MAX_PERIOD, MAX_DAILY_RATE]], ...]].
+// Missing or null limits are left zero.
+func (api *BitfinexExt) FundingInfo() (currencies map[string]CurrencyInfo, err error) {
+	var result [][][]json.RawMessage
+	err = api.getV2("conf/pub:info:funding", &result)
+	if err != nil || len(result) == 0 {
+		return
+	}
+
+	currencies = map[string]CurrencyInfo{}
+	for _, entry := range result[0] {
+		var cur string
+		var limits []interface{}
+		if len(entry) != 2 || json.Unmarshal(entry[0], &cur) != nil || json.Unmarshal(entry[1], &limits) != nil {
+			continue
+		}
+
+		field := func(i int) float64 {
+			if i >= len(limits) {
+				return 0
+			}
+
+			switch v := limits[i].(type) {
+			case float64:
+				return v
+			case string:
+				f, _ := strconv.ParseFloat(v, 64)
+				return f
+			}
+
+			return 0
+		}
+
+		currencies[strings.ToLower(cur)] = CurrencyInfo{MinOfferSize: field(0), AmountPlaces: int(field(1)),
+			MinPeriod: int(field(2)), MaxPeriod: int(field(3)), MaxDailyRate: field(4)}
+	}
+
+	return
+}
+
+// DefaultCurrencyInfo is used for whatever the exchange and the config do not specify
+func DefaultCurrencyInfo() CurrencyInfo {
+	return CurrencyInfo{
+		AmountPlaces: DecimalPlaces,
+		MinPeriod:    MinLendPeriod,
+		MaxPeriod:    MaxLendPeriod,
+		MaxDailyRate: maxPlausibleDailyRate,
+	}
+}
+
+// merge returns c with the non-zero fields of o
+func (c CurrencyInfo) merge(o CurrencyInfo) CurrencyInfo {
+	if o.MinOfferSize > 0 {
+		c.MinOfferSize = o.MinOfferSize
+	}
+	if o.AmountPlaces > 0 {
+		c.AmountPlaces = o.AmountPlaces
+	}
+	if o.MinPeriod > 0 {
+		c.MinPeriod = o.MinPeriod
+	}
+	if o.MaxPeriod > 0 {
+		c.MaxPeriod = o.MaxPeriod
+	}
+	if o.MaxDailyRate > 0 {
+		c.MaxDailyRate = o.MaxDailyRate
+	}
+
+	return c
+}
+
+// ClampPeriod limits the period to the range allowed for the currency
+func (c CurrencyInfo) ClampPeriod(period int) int {
+	if period < c.MinPeriod {
+		return c.MinPeriod
+	}
+	if period > c.MaxPeriod {
+		return c.MaxPeriod
+	}
+
+	return period
+}
+
+// LoadCurrencyRegistry reads the cached metadata, a missing cache is fetched on first use
+func LoadCurrencyRegistry(path string) (r *CurrencyRegistry, err error) {
+	r = &CurrencyRegistry{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, r)
+
+	return
+}
+
+func (r *CurrencyRegistry) save() (err error) {
+	if r.path == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return
+	}
+
+	tmp := r.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return
+	}
+
+	return os.Rename(tmp, r.path)
+}
+
+// refresh fetches the metadata from the exchange if the cache is stale.
+// A failed fetch keeps using the cached metadata.
+func (r *CurrencyRegistry) refresh(api *BitfinexExt, now time.Time, logger *log.Logger) {
+	if api == nil || (r.Currencies != nil && now.Sub(r.Updated) < currencyCacheTTL) {
+		return
+	}
+
+	logger.Println("\tGetting currency funding limits...")
+
+	currencies, err := api.FundingInfo()
+	if err != nil {
+		logger.Println("\tWARNING: Failed to get currency funding limits, using cached values: " + err.Error())
+		return
+	}
+
+	r.Currencies = currencies
+	r.Updated = now
+
+	err = r.save()
+	if err != nil {
+		logger.Println("\tWARNING: Failed to save currency metadata cache: " + err.Error())
+	}
+}
+
+// Info returns the metadata of the currency: defaults, overridden by the exchange's funding limits,
+// overridden by the config. Override keys differing only in case are applied in sorted order.
+func (r *CurrencyRegistry) Info(api *BitfinexExt, currency string, overrides map[string]CurrencyInfo, logger *log.Logger) CurrencyInfo {
+	currency = strings.ToLower(currency)
+	info := DefaultCurrencyInfo()
+
+	if r != nil {
+		r.lock.Lock()
+		r.refresh(api, time.Now().UTC(), logger)
+		info = info.merge(r.Currencies[currency])
+		r.lock.Unlock()
+	}
+
+	var keys []string
+	for cur := range overrides {
+		if strings.ToLower(cur) == currency {
+			keys = append(keys, cur)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, cur := range keys {
+		info = info.merge(overrides[cur])
+	}
+
+	return info
+}
+
+// Lending period range allowed by the exchange (in days)
+const (
+	MinLendPeriod = 2
+	MaxLendPeriod = 30
+)
@@ -0,0 +1,110 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFundingInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[[["USD",[150,8,2,120,7]],["BTC",["0.002",null,2,30]],["bad"]]]`))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { APIV2URL = url }(APIV2URL)
+	APIV2URL = srv.URL + "/v2/"
+
+	currencies, err := NewBitfinexExt("", "").FundingInfo()
+	if err != nil {
+		t.Fatal("Failed to get funding info: " + err.Error())
+	}
+
+	if usd := currencies["usd"]; usd != (CurrencyInfo{MinOfferSize: 150, AmountPlaces: 8, MinPeriod: 2, MaxPeriod: 120, MaxDailyRate: 7}) {
+		t.Errorf("Returned wrong usd limits %+v", usd)
+	}
+
+	if btc := currencies["btc"]; btc != (CurrencyInfo{MinOfferSize: 0.002, MinPeriod: 2, MaxPeriod: 30}) {
+		t.Errorf("Returned wrong btc limits %+v", btc)
+	}
+
+	if len(currencies) != 2 {
+		t.Error("Returned wrong number of currencies (" + strconv.Itoa(len(currencies)) + ", expected: 2)")
+	}
+}
+
+func TestCurrencyRegistry(t *testing.T) {
+	requests, fail := 0, false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if fail || r.URL.Path != "/v2/conf/pub:info:funding" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Write([]byte(`[[["BTC",[0.002,null,null,60]]]]`))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { APIV2URL = url }(APIV2URL)
+	APIV2URL = srv.URL + "/v2/"
+
+	dir, err := ioutil.TempDir("", "blb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "currencies")
+	r, err := LoadCurrencyRegistry(path)
+	if err != nil {
+		t.Fatal("Failed to load missing cache: " + err.Error())
+	}
+
+	api := NewBitfinexExt("key", "secret")
+	logger := log.New(ioutil.Discard, "", 0)
+	overrides := map[string]CurrencyInfo{"BTC": {MaxPeriod: 14}}
+
+	info := r.Info(api, "btc", overrides, logger)
+	if info.MinOfferSize != 0.002 || info.AmountPlaces != DecimalPlaces || info.MinPeriod != MinLendPeriod || info.MaxPeriod != 14 {
+		t.Errorf("Returned wrong btc metadata %+v", info)
+	}
+
+	// Cached for the next runs
+	if info := r.Info(api, "btc", nil, logger); info.MaxPeriod != 60 || requests != 1 {
+		t.Error("Metadata fetched " + strconv.Itoa(requests) + " times, expected: 1")
+	}
+
+	r, err = LoadCurrencyRegistry(path)
+	if err != nil || r.Currencies["btc"].MinOfferSize != 0.002 {
+		t.Fatal("Metadata not cached to file")
+	}
+
+	// Stale cache is used if the exchange fails
+	fail = true
+	r.Updated = time.Now().Add(-2 * currencyCacheTTL)
+	if info := r.Info(api, "btc", nil, logger); info.MinOfferSize != 0.002 || requests != 2 {
+		t.Error("Stale cache not used after a failed fetch")
+	}
+
+	// Unknown currencies get the defaults
+	if info := r.Info(api, "xyz", nil, logger); info != DefaultCurrencyInfo() {
+		t.Errorf("Returned wrong default metadata %+v", info)
+	}
+
+	// Overrides differing only in case apply in a fixed order
+	overrides = map[string]CurrencyInfo{"xyz": {MinOfferSize: 2}, "XYZ": {MinOfferSize: 1}, "Xyz": {MinOfferSize: 3}}
+	for i := 0; i < 10; i++ {
+		if info := r.Info(api, "xyz", overrides, logger); info.MinOfferSize != 2 {
+			t.Fatal("Case duplicate overrides applied in random order (" + strconv.FormatFloat(info.MinOfferSize, 'f', -1, 64) + ", expected: 2)")
+		}
+	}
+}
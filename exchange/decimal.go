@@ -0,0 +1,257 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decimal precision, the exchange accepts at most 8 decimal places
+const (
+	DecimalPlaces = 8
+	decimalScale  = 100000000
+
+	RatePlaces = 6 // Offer rates, %/year
+)
+
+// RoundingMode ...
+type RoundingMode int
+
+// Rounding modes
+const (
+	RoundDown     RoundingMode = iota // Towards zero
+	RoundUp                           // Away from zero
+	RoundHalfEven                     // To nearest, ties to even
+)
+
+// Decimal is a fixed-point number with 8 decimal places, used for currency
+// amounts and rates so that splits and sums are exact
+type Decimal struct {
+	units int64 // Value * decimalScale
+}
+
+// DecimalFromFloat converts a float to the nearest Decimal
+func DecimalFromFloat(f float64) Decimal {
+	return Decimal{int64(math.Round(f * decimalScale))}
+}
+
+// DecimalFromInt ...
+func DecimalFromInt(i int64) Decimal {
+	return Decimal{i * decimalScale}
+}
+
+// AmountDecimal converts an exchange amount to a Decimal with the given decimal places,
+// rounding down so that it never exceeds what is there
+func AmountDecimal(f float64, places int) Decimal {
+	return DecimalFromFloat(f).Round(places, RoundDown)
+}
+
+// MinLoanDecimal converts the minimum loan size, rounding up so that offers are never below it
+func MinLoanDecimal(minLoan float64, places int) Decimal {
+	return DecimalFromFloat(minLoan).Round(places, RoundUp)
+}
+
+// RateDecimal converts an offer rate (%/year) to a Decimal, rounding up
+// so that it is never below the rate the strategy asked for
+func RateDecimal(yearlyRate float64) Decimal {
+	return DecimalFromFloat(yearlyRate).Round(RatePlaces, RoundUp)
+}
+
+// ParseDecimal parses a decimal number with at most 8 decimal places
+func ParseDecimal(s string) (d Decimal, err error) {
+	s = strings.TrimSpace(s)
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	parts := strings.SplitN(s, ".", 2)
+	if parts[0] == "" && (len(parts) == 1 || parts[1] == "") {
+		return d, errors.New("Invalid decimal: \"" + s + "\"")
+	}
+
+	intPart := int64(0)
+	if parts[0] != "" {
+		intPart, err = strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || intPart > math.MaxInt64/decimalScale {
+			return d, errors.New("Invalid decimal: \"" + s + "\"")
+		}
+	}
+
+	frac := int64(0)
+	if len(parts) == 2 {
+		digits := parts[1]
+		if len(digits) > DecimalPlaces {
+			return d, errors.New("Decimal \"" + s + "\" has more than " + strconv.Itoa(DecimalPlaces) + " decimal places")
+		}
+
+		digits += strings.Repeat("0", DecimalPlaces-len(digits))
+		frac, err = strconv.ParseInt(digits, 10, 64)
+		if err != nil || frac < 0 {
+			return d, errors.New("Invalid decimal: \"" + s + "\"")
+		}
+	}
+
+	d.units = intPart*decimalScale + frac
+	if neg {
+		d.units = -d.units
+	}
+
+	return
+}
+
+// MustDecimal parses a decimal constant
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (d Decimal) String() string {
+	sign := ""
+	u := d.units
+	if u < 0 {
+		sign = "-"
+		u = -u
+	}
+
+	s := strconv.FormatInt(u/decimalScale, 10)
+	if frac := u % decimalScale; frac != 0 {
+		fs := strconv.FormatInt(frac, 10)
+		fs = strings.Repeat("0", DecimalPlaces-len(fs)) + fs
+		s += "." + strings.TrimRight(fs, "0")
+	}
+
+	return sign + s
+}
+
+// Float64 ...
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / decimalScale
+}
+
+// Add ...
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{d.units + o.units}
+}
+
+// Sub ...
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{d.units - o.units}
+}
+
+// Cmp returns -1, 0 or 1 if d is less than, equal to or greater than o
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.units < o.units:
+		return -1
+	case d.units > o.units:
+		return 1
+	}
+
+	return 0
+}
+
+// IsZero ...
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// MinDecimal ...
+func MinDecimal(a, b Decimal) Decimal {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+
+	return b
+}
+
+// MaxDecimal ...
+func MaxDecimal(a, b Decimal) Decimal {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+
+	return b
+}
+
+// Round rounds d to the given number of decimal places
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	if places >= DecimalPlaces {
+		return d
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	step := int64(math.Pow10(DecimalPlaces - places))
+
+	return Decimal{divRound(d.units, step, mode) * step}
+}
+
+// DivInt divides d by n, rounding the result to the given number of decimal places
+func (d Decimal) DivInt(n int, places int, mode RoundingMode) Decimal {
+	if places > DecimalPlaces {
+		places = DecimalPlaces
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	step := int64(math.Pow10(DecimalPlaces - places))
+
+	return Decimal{divRound(d.units, int64(n)*step, mode) * step}
+}
+
+// divRound returns a / b rounded according to the mode, b must be positive
+func divRound(a, b int64, mode RoundingMode) int64 {
+	q, r := a/b, a%b
+	if r == 0 {
+		return q
+	}
+
+	sign := int64(1)
+	if a < 0 {
+		sign = -1
+		r = -r
+	}
+
+	switch mode {
+	case RoundUp:
+		return q + sign
+	case RoundHalfEven:
+		if 2*r > b || (2*r == b && q%2 != 0) {
+			return q + sign
+		}
+	}
+
+	return q
+}
+
+// MarshalJSON writes the exact value as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or string
+func (d *Decimal) UnmarshalJSON(data []byte) (err error) {
+	s := strings.Trim(string(data), "\"")
+
+	*d, err = ParseDecimal(s)
+	if err != nil {
+		// Floats written by older versions (e.g. 1e-05)
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return
+		}
+
+		*d, err = DecimalFromFloat(f), nil
+	}
+
+	return
+}
@@ -0,0 +1,120 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"0", "0"},
+		{"100", "100"},
+		{"0.1", "0.1"},
+		{".5", "0.5"},
+		{"-1.25", "-1.25"},
+		{"+3.10000000", "3.1"},
+		{"0.00000001", "0.00000001"},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Error("Failed to parse \"" + tt.in + "\": " + err.Error())
+			continue
+		}
+
+		if d.String() != tt.out {
+			t.Error("Parsed \"" + tt.in + "\" as " + d.String() + ", expected: " + tt.out)
+		}
+	}
+
+	for _, in := range []string{"", ".", "-", "1.2.3", "abc", "0.000000001", "1e5"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Error("Invalid decimal \"" + in + "\" was accepted")
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int
+		mode   RoundingMode
+		out    string
+	}{
+		{"1.005", 2, RoundDown, "1"},
+		{"1.005", 2, RoundUp, "1.01"},
+		{"1.005", 2, RoundHalfEven, "1"},
+		{"1.015", 2, RoundHalfEven, "1.02"},
+		{"1.0151", 2, RoundHalfEven, "1.02"},
+		{"-1.005", 2, RoundDown, "-1"},
+		{"-1.005", 2, RoundUp, "-1.01"},
+		{"1.23456789", 8, RoundUp, "1.23456789"},
+		{"1.5", 0, RoundDown, "1"},
+	}
+
+	for _, tt := range tests {
+		out := MustDecimal(tt.in).Round(tt.places, tt.mode).String()
+		if out != tt.out {
+			t.Error("Rounded " + tt.in + " to " + strconv.Itoa(tt.places) + " places as " + out + ", expected: " + tt.out)
+		}
+	}
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	// The float sum is 0.30000000000000004
+	if d := DecimalFromFloat(0.1 + 0.2); d != MustDecimal("0.3") {
+		t.Error("Converted 0.1 + 0.2 to " + d.String() + ", expected: 0.3")
+	}
+
+	if d := RateDecimal(3.3 * 365); d != MustDecimal("1204.5") {
+		t.Error("Converted rate 3.3 * 365 to " + d.String() + ", expected: 1204.5")
+	}
+
+	// Minimum loan is rounded up, so that offers are never below it
+	if d := MinLoanDecimal(50/3.0, 2); d != MustDecimal("16.67") {
+		t.Error("Converted minimum loan 50 / 3 to " + d.String() + ", expected: 16.67")
+	}
+}
+
+func TestDecimalDivInt(t *testing.T) {
+	for _, total := range []string{"100", "0.00000007", "1234.56789012", "1"} {
+		for n := 1; n <= 7; n++ {
+			for places := 0; places <= DecimalPlaces; places++ {
+				d := MustDecimal(total)
+				each := d.DivInt(n, places, RoundDown)
+
+				sum := Decimal{}
+				for i := 0; i < n; i++ {
+					sum = sum.Add(each)
+				}
+
+				if sum.Cmp(d) > 0 || each.Round(places, RoundDown) != each {
+					t.Error(total + " split into " + strconv.Itoa(n) + " at " + strconv.Itoa(places) + " places gives " + each.String())
+				}
+			}
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Amount Decimal }{MustDecimal("0.00000001")})
+	if err != nil || string(data) != `{"Amount":0.00000001}` {
+		t.Error("Unexpected JSON: " + string(data))
+	}
+
+	var v struct{ A, B, C Decimal }
+	err = json.Unmarshal([]byte(`{"A": 12.5, "B": "0.1", "C": 1e-05}`), &v)
+	if err != nil || v.A != MustDecimal("12.5") || v.B != MustDecimal("0.1") || v.C != MustDecimal("0.00001") {
+		t.Error("Failed to read decimals from JSON")
+	}
+
+	if json.Unmarshal([]byte(`{"A": "abc"}`), &v) == nil {
+		t.Error("Invalid decimal was accepted")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Market data problems that stop a strategy from running
+const (
+	MarketEmptyLendbook    MarketDataReason = "empty_lendbook"
+	MarketUnsortedLendbook MarketDataReason = "unsorted_lendbook"
+	MarketBadAmount        MarketDataReason = "bad_amount"
+	MarketImplausibleRate  MarketDataReason = "implausible_rate"
+	MarketNoFRR            MarketDataReason = "no_frr"
+	MarketStaleTicker      MarketDataReason = "stale_ticker"
+	MarketBadPrice         MarketDataReason = "bad_price"
+)
+
+const (
+	maxPlausibleDailyRate = 7.0 // %/day, anything above is treated as bad data
+	maxTickerAge          = 10 * time.Minute
+)
+
+// MarketDataReason ...
+type MarketDataReason string
+
+// MarketDataError is returned when exchange data fails validation
+type MarketDataError struct {
+	Reason  MarketDataReason
+	Message string
+}
+
+func (e *MarketDataError) Error() string {
+	return "Bad market data (" + string(e.Reason) + "): " + e.Message
+}
+
+// NewMarketDataError ...
+func NewMarketDataError(reason MarketDataReason, msg string) *MarketDataError {
+	return &MarketDataError{Reason: reason, Message: msg}
+}
+
+// ReasonOf returns the reason of a market data error, empty for any other error
+func ReasonOf(err error) MarketDataReason {
+	if mde, ok := err.(*MarketDataError); ok {
+		return mde.Reason
+	}
+
+	return ""
+}
+
+// ValidateLendbook checks that the lend side of the book is usable by the strategies
+func ValidateLendbook(lendbook bitfinex.Lendbook) error {
+	if len(lendbook.Asks) == 0 {
+		return NewMarketDataError(MarketEmptyLendbook, "Lendbook has no asks")
+	}
+
+	for i, o := range lendbook.Asks {
+		pos := "ask #" + strconv.Itoa(i)
+
+		if !(o.Amount > 0) {
+			return NewMarketDataError(MarketBadAmount, pos+" has amount "+formatFloat(o.Amount))
+		}
+
+		if !(o.Rate > 0) || o.Rate/365 > maxPlausibleDailyRate {
+			return NewMarketDataError(MarketImplausibleRate, pos+" has rate "+rateStr(o.Rate/365))
+		}
+
+		if i > 0 && o.Rate < lendbook.Asks[i-1].Rate {
+			return NewMarketDataError(MarketUnsortedLendbook, pos+" rate "+rateStr(o.Rate/365)+" is lower than the previous ask")
+		}
+	}
+
+	return nil
+}
+
+// ValidateTicker checks that the price can be used to convert USD amounts
+func ValidateTicker(ticker bitfinex.Ticker, now time.Time) error {
+	if !(ticker.Mid > 0) || math.IsInf(ticker.Mid, 0) {
+		return NewMarketDataError(MarketBadPrice, "Ticker mid price is "+formatFloat(ticker.Mid))
+	}
+
+	// Timestamp is optional, but an old one means the price is not being updated
+	if ticker.Timestamp > 0 {
+		age := now.Sub(time.Unix(int64(ticker.Timestamp), 0))
+		if age > maxTickerAge {
+			return NewMarketDataError(MarketStaleTicker, "Ticker is "+age.Truncate(time.Second).String()+" old")
+		}
+	}
+
+	return nil
+}
+
+// LendbookDailyFRR returns the daily FRR from the lendbook or 0 if it is not present
+func LendbookDailyFRR(lendbook bitfinex.Lendbook) float64 {
+	for _, o := range lendbook.Asks {
+		if o.FRR {
+			return o.Rate / 365
+		}
+	}
+
+	return 0
+}
+
+// WithoutOffers returns the lendbook without the given lend offers, so that the strategies
+// price against the rest of the market instead of the offers they are about to replace
+func WithoutOffers(lendbook bitfinex.Lendbook, offers bitfinex.Offers) bitfinex.Lendbook {
+	asks := append([]bitfinex.LendbookOffer{}, lendbook.Asks...)
+
+	for _, o := range offers {
+		for i := range asks {
+			if asks[i].FRR || math.Abs(asks[i].Rate-o.Rate) > 1e-9 || asks[i].Amount <= 0 {
+				continue
+			}
+
+			// Levels may aggregate several offers at the same rate
+			asks[i].Amount -= math.Min(asks[i].Amount, o.RemainingAmount)
+			break
+		}
+	}
+
+	lendbook.Asks = nil
+	for _, a := range asks {
+		if a.Amount > 1e-9 {
+			lendbook.Asks = append(lendbook.Asks, a)
+		}
+	}
+
+	return lendbook
+}
+
+// DepthLevel ...
+type DepthLevel struct {
+	Rate   float64 // %/year
+	Amount float64
+	FRR    bool `json:",omitempty"`
+}
+
+// ActiveLendOffers returns active lend offers in the currency
+func ActiveLendOffers(api *bitfinex.API, currency string) (offers bitfinex.Offers, err error) {
+	// Get all active offers
+	allOffers, err := api.ActiveOffers()
+	if err != nil {
+		return
+	}
+
+	// Filter only relevant offers
+	for _, o := range allOffers {
+		if strings.ToLower(o.Currency) == currency && strings.ToLower(o.Direction) == bitfinex.LEND {
+			offers = append(offers, o)
+		}
+	}
+
+	return
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func rateStr(dailyRate float64) string {
+	return strconv.FormatFloat(dailyRate, 'g', 10, 64) + " %/day"
+}
@@ -0,0 +1,82 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestValidateLendbook(t *testing.T) {
+	ask := func(dailyRate, amount float64) bitfinex.LendbookOffer {
+		return bitfinex.LendbookOffer{Rate: dailyRate * 365, Amount: amount}
+	}
+
+	tests := []struct {
+		asks   []bitfinex.LendbookOffer
+		reason MarketDataReason
+	}{
+		{nil, MarketEmptyLendbook},
+		{[]bitfinex.LendbookOffer{ask(0.1, 10), ask(0.05, 10)}, MarketUnsortedLendbook},
+		{[]bitfinex.LendbookOffer{ask(0.1, 10), ask(0.2, 0)}, MarketBadAmount},
+		{[]bitfinex.LendbookOffer{ask(0.1, math.NaN())}, MarketBadAmount},
+		{[]bitfinex.LendbookOffer{ask(0, 10)}, MarketImplausibleRate},
+		{[]bitfinex.LendbookOffer{ask(0.1, 10), ask(50, 10)}, MarketImplausibleRate},
+		{[]bitfinex.LendbookOffer{ask(0.1, 10), ask(0.1, 5), ask(0.2, 10)}, ""},
+	}
+
+	for i, test := range tests {
+		err := ValidateLendbook(bitfinex.Lendbook{Asks: test.asks})
+		if reason := ReasonOf(err); reason != test.reason {
+			t.Errorf("Case %d: returned reason \"%s\" (%v), expected: \"%s\"", i, reason, err, test.reason)
+		}
+	}
+}
+
+func TestValidateTicker(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		ticker bitfinex.Ticker
+		reason MarketDataReason
+	}{
+		{bitfinex.Ticker{Mid: 0}, MarketBadPrice},
+		{bitfinex.Ticker{Mid: -1}, MarketBadPrice},
+		{bitfinex.Ticker{Mid: 400, Timestamp: float64(now.Add(-time.Hour).Unix())}, MarketStaleTicker},
+		{bitfinex.Ticker{Mid: 400, Timestamp: float64(now.Add(-time.Minute).Unix())}, ""},
+		{bitfinex.Ticker{Mid: 400}, ""},
+	}
+
+	for i, test := range tests {
+		err := ValidateTicker(test.ticker, now)
+		if reason := ReasonOf(err); reason != test.reason {
+			t.Errorf("Case %d: returned reason \"%s\" (%v), expected: \"%s\"", i, reason, err, test.reason)
+		}
+	}
+}
+
+func TestWithoutOffers(t *testing.T) {
+	lendbook := bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{
+		{Rate: 36.5, Amount: 100},
+		{Rate: 40, Amount: 300}, // Own offer only
+		{Rate: 43.8, Amount: 500, FRR: true},
+		{Rate: 50, Amount: 250}, // Own offer and another lender
+	}}
+	offers := bitfinex.Offers{
+		{ID: 1, Rate: 40, RemainingAmount: 300},
+		{ID: 2, Rate: 50, RemainingAmount: 50},
+		{ID: 3, Rate: 60, RemainingAmount: 10}, // Not in the book yet
+	}
+
+	asks := WithoutOffers(lendbook, offers).Asks
+	if len(asks) != 3 || asks[0].Rate != 36.5 || asks[1].Rate != 43.8 || asks[1].Amount != 500 || asks[2].Rate != 50 || asks[2].Amount != 200 {
+		t.Errorf("Returned wrong asks (%v, expected: 36.5 x 100, 43.8 x 500 FRR, 50 x 200)", asks)
+	}
+
+	if len(lendbook.Asks) != 4 || lendbook.Asks[3].Amount != 250 {
+		t.Error("Modified the original lendbook")
+	}
+}
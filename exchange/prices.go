@@ -0,0 +1,125 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Currencies tried as the middle leg when there is no direct pair
+var crossCurrencies = []string{"usd", "btc", "eth"}
+
+// PriceQuote is the last known price of a currency pair
+type PriceQuote struct {
+	Price float64
+	Time  time.Time
+}
+
+// PriceOracle resolves the price of any currency in another one via direct, inverse
+// or cross pairs. Prices are cached for TTL, and the last known price is used
+// when the exchange cannot provide a fresh one.
+type PriceOracle struct {
+	TTL   time.Duration
+	Known map[string]PriceQuote // By "from/to", kept in the state
+
+	lock sync.Mutex
+}
+
+type tickerFunc func(symbol string) (bitfinex.Ticker, error)
+
+// NewPriceOracle returns an oracle that starts with the known quotes and refetches them after ttl
+func NewPriceOracle(known map[string]PriceQuote, ttl time.Duration) *PriceOracle {
+	if known == nil {
+		known = map[string]PriceQuote{}
+	}
+
+	return &PriceOracle{TTL: ttl, Known: known}
+}
+
+// Price returns the value of one unit of currency from in currency to.
+// Falling back to the last known price is logged to logger, if not nil.
+func (o *PriceOracle) Price(ticker tickerFunc, from, to string, logger *log.Logger) (price float64, err error) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	if from == to {
+		return 1, nil
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	now := time.Now().UTC()
+
+	price, err = o.pair(ticker, from, to, now)
+	if err == nil {
+		return
+	}
+
+	for _, via := range crossCurrencies {
+		if via == from || via == to {
+			continue
+		}
+
+		a, aerr := o.pair(ticker, from, via, now)
+		if aerr != nil {
+			continue
+		}
+
+		b, berr := o.pair(ticker, via, to, now)
+		if berr != nil {
+			continue
+		}
+
+		price = a * b
+		o.Known[from+"/"+to] = PriceQuote{Price: price, Time: now}
+		return price, nil
+	}
+
+	if q, ok := o.Known[from+"/"+to]; ok {
+		if logger != nil {
+			logger.Println("\tWARNING: Failed to get " + from + "/" + to + " price, using last known from " +
+				q.Time.Local().Format("2006-01-02 15:04:05") + ": " + err.Error())
+		}
+		return q.Price, nil
+	}
+
+	return 0, err
+}
+
+// pair returns a cached price or the price from the direct or the inverse ticker
+func (o *PriceOracle) pair(ticker tickerFunc, from, to string, now time.Time) (float64, error) {
+	key := from + "/" + to
+	if q, ok := o.Known[key]; ok && now.Sub(q.Time) < o.TTL {
+		return q.Price, nil
+	}
+
+	t, err := ticker(from + to)
+	if err == nil {
+		err = ValidateTicker(t, now)
+	}
+	if err == nil {
+		o.Known[key] = PriceQuote{Price: t.Mid, Time: now}
+		return t.Mid, nil
+	}
+
+	t, ierr := ticker(to + from)
+	if ierr == nil {
+		ierr = ValidateTicker(t, now)
+	}
+	if ierr == nil {
+		o.Known[key] = PriceQuote{Price: 1 / t.Mid, Time: now}
+		return 1 / t.Mid, nil
+	}
+
+	// Report the direct pair's problem, it is the expected one
+	if _, ok := err.(*MarketDataError); !ok {
+		err = errors.New("Failed to get " + from + to + " ticker: " + err.Error())
+	}
+
+	return 0, err
+}
@@ -0,0 +1,99 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package exchange
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// testTicker serves mid prices by symbol and counts the calls
+func testTicker(mids map[string]float64, calls *int) tickerFunc {
+	return func(symbol string) (bitfinex.Ticker, error) {
+		*calls++
+		mid, ok := mids[symbol]
+		if !ok {
+			return bitfinex.Ticker{}, errors.New("Unknown symbol")
+		}
+
+		return bitfinex.Ticker{Mid: mid}, nil
+	}
+}
+
+func TestPriceOracle_Routes(t *testing.T) {
+	calls := 0
+	ticker := testTicker(map[string]float64{"btcusd": 400, "ethbtc": 0.05, "usdeur": 0.8}, &calls)
+	o := NewPriceOracle(nil, time.Minute)
+
+	tests := []struct {
+		from, to string
+		price    float64
+	}{
+		{"usd", "usd", 1},
+		{"btc", "usd", 400},       // Direct
+		{"usd", "btc", 1.0 / 400}, // Inverse
+		{"eth", "usd", 20},        // Cross via btc
+		{"eth", "eur", 16},        // Cross via usd, eth/usd is cached
+		{"eur", "usd", 1.0 / 0.8}, // Inverse
+		{"BTC", "EUR", 400 * 0.8}, // Case insensitive
+	}
+
+	for _, tt := range tests {
+		price, err := o.Price(ticker, tt.from, tt.to, nil)
+		if err != nil {
+			t.Error("Failed to get " + tt.from + "/" + tt.to + " price: " + err.Error())
+			continue
+		}
+
+		if math.Abs(price-tt.price) > 0.0000000001 {
+			t.Error("Returned wrong " + tt.from + "/" + tt.to + " price (" + strconv.FormatFloat(price, 'f', -1, 64) +
+				", expected: " + strconv.FormatFloat(tt.price, 'f', -1, 64) + ")")
+		}
+	}
+
+	if _, err := o.Price(ticker, "xyz", "usd", nil); err == nil {
+		t.Error("Price of an unknown currency returned")
+	}
+}
+
+func TestPriceOracle_Cache(t *testing.T) {
+	calls := 0
+	mids := map[string]float64{"btcusd": 400}
+	ticker := testTicker(mids, &calls)
+	o := NewPriceOracle(nil, time.Minute)
+
+	o.Price(ticker, "btc", "usd", nil)
+	o.Price(ticker, "btc", "usd", nil)
+	if calls != 1 {
+		t.Error("Ticker called " + strconv.Itoa(calls) + " times, expected: 1")
+	}
+
+	// Expired prices are fetched again
+	o.Known["btc/usd"] = PriceQuote{Price: 300, Time: time.Now().Add(-time.Hour)}
+	if price, _ := o.Price(ticker, "btc", "usd", nil); price != 400 || calls != 2 {
+		t.Error("Expired price not refreshed (" + strconv.FormatFloat(price, 'f', -1, 64) + ", expected: 400)")
+	}
+
+	// Last known price is used when the ticker is down
+	delete(mids, "btcusd")
+	o.Known["btc/usd"] = PriceQuote{Price: 300, Time: time.Now().Add(-time.Hour)}
+	if price, err := o.Price(ticker, "btc", "usd", nil); err != nil || price != 300 {
+		t.Error("Last known price not used (" + strconv.FormatFloat(price, 'f', -1, 64) + ", expected: 300)")
+	}
+}
+
+func TestPriceOracle_BadTicker(t *testing.T) {
+	ticker := func(symbol string) (bitfinex.Ticker, error) {
+		return bitfinex.Ticker{Mid: 400, Timestamp: float64(time.Now().Add(-time.Hour).Unix())}, nil
+	}
+
+	_, err := NewPriceOracle(nil, time.Minute).Price(ticker, "btc", "usd", nil)
+	if ReasonOf(err) != MarketStaleTicker {
+		t.Errorf("Returned wrong error (%v, expected: %s)", err, MarketStaleTicker)
+	}
+}
@@ -1,16 +1,18 @@
 // Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
 
+// BitfinexLendingBot is the command line interface of the bot package
 package main
 
 import (
-	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
-	"strconv"
-	"strings"
+	"time"
 
-	"github.com/eAndrius/bitfinex-go"
+	"github.com/eAndrius/BitfinexLendingBot/bot"
+	"github.com/eAndrius/BitfinexLendingBot/config"
 )
 
 var (
@@ -18,77 +20,142 @@ var (
 	updateLends = flag.Bool("updatelends", false, "Update lend offerings")
 	dryRun      = flag.Bool("dryrun", false, "Output strategy decisions without placing orders")
 	logToFile   = flag.Bool("logtofile", false, "Write log to file instead of stdout")
+	stateFile   = flag.String("state", "blb.state", "Local state file")
+	jsonOutput  = flag.Bool("json", false, "Output command results as JSON")
+	csvOutput   = flag.Bool("csv", false, "Output command results as CSV (where supported)")
+	reportSince = flag.String("since", "", "Report start date (YYYY-MM-DD), defaults to 30 days ago")
+	reportUntil = flag.String("until", "", "Report end date (YYYY-MM-DD, exclusive), defaults to now")
+	listenAddr  = flag.String("listen", "127.0.0.1:8484", "Control API listen address for the serve command (empty to disable)")
+	apiToken    = flag.String("apitoken", os.Getenv("BLB_API_TOKEN"), "Control API bearer token (defaults to $BLB_API_TOKEN)")
+	runInterval = flag.Duration("interval", 10*time.Minute, "Run interval for the serve command (0 to only run on request)")
+	accountSel  = flag.String("account", "", "Only use the account with this name (or API key)")
+	cancelAll   = flag.Bool("all", false, "Select all lend offers (cancel, adopt commands)")
+	cancelID    = flag.Int("id", 0, "Offer ID to select (cancel, adopt commands)")
+	planOut     = flag.String("out", "", "Write the plan to this file for a later apply (plan command)")
+	tolerance   = flag.Float64("tolerance", 1, "Allowed change of balances and offers since the plan, % (apply command)")
+	killSwitch  = flag.String("killswitch", "blb.halt", "Halt all order placement while this file exists")
+	explain     = flag.Bool("explain", false, "Show how each offer's amount, rate and period were derived")
+	currencyDB  = flag.String("currencies", "blb.currencies", "Currency metadata cache file")
+	priceTTL    = flag.Duration("pricettl", time.Minute, "How long prices are cached")
+	reportCur   = flag.String("reportcurrency", "usd", "Currency to value reports and metrics in")
 )
 
-// BotConfig ...
-type BotConfig struct {
-	Bitfinex BitfinexConf
-	Strategy StrategyConf
-
-	API *bitfinex.API
-}
-
-// BotConfigs ...
-type BotConfigs []BotConfig
-
-// BitfinexConf ...
-type BitfinexConf struct {
-	APIKey          string
-	APISecret       string
-	ActiveWallet    string
-	MaxActiveAmount float64
-	MinLoanUSD      float64
-}
-
-func init() {
+func main() {
+	flag.Usage = usage
 	flag.Parse()
 
+	command, args := flag.Arg(0), []string{}
+	if flag.NArg() > 1 {
+		var err error
+		args, err = commandArgs(flag.Args()[1:])
+		if err != nil {
+			os.Exit(2)
+		}
+	}
+
+	var logOutput io.Writer = os.Stderr
 	if *logToFile {
 		f, err := os.OpenFile("blb.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 		if err != nil {
 			panic("error opening file: " + err.Error())
 		}
 
+		logOutput = f
 		log.SetOutput(f)
 	}
-}
 
-func main() {
-	file, err := os.Open(*configFile)
+	confs, err := config.Load(*configFile)
 	if err != nil {
-		log.Fatal("Failed to open config file: " + err.Error())
+		log.Fatal(err)
 	}
 
-	decoder := json.NewDecoder(file)
-	confs := BotConfigs{}
-	err = decoder.Decode(&confs)
+	confs, err = selectAccounts(confs, *accountSel)
 	if err != nil {
-		log.Fatal("Failed to parse config file:" + err.Error())
+		log.Fatal(err)
 	}
 
-	for _, conf := range confs {
-		log.Println("Using Bitfinex user API key: " + conf.Bitfinex.APIKey)
-		conf.API = bitfinex.New(conf.Bitfinex.APIKey, conf.Bitfinex.APISecret)
+	b, err := bot.New(bot.Config{
+		Accounts:       confs,
+		StateFile:      *stateFile,
+		CurrencyCache:  *currencyDB,
+		PriceTTL:       *priceTTL,
+		KillSwitch:     *killSwitch,
+		ReportCurrency: *reportCur,
+		Explain:        *explain,
+		LogOutput:      logOutput,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		balance, err := conf.API.WalletBalances()
-		if err != nil {
-			log.Println("WARNING: Failed to get wallet funds, skipping: " + err.Error())
-			continue
-		}
+	confs, st := b.Confs, b.State
+
+	switch command {
+	case "":
+		err = b.RunOnce("", *updateLends, *dryRun)
+	case "run":
+		err = b.RunOnce("", true, *dryRun)
+	case "status":
+		err = cmdStatus(b, os.Stdout)
+	case "offers":
+		err = cmdOffers(confs, os.Stdout)
+	case "balances":
+		err = cmdBalances(confs, os.Stdout)
+	case "lendbook":
+		err = cmdLendbook(confs, args, os.Stdout)
+	case "adopt":
+		err = cmdAdopt(confs, args, os.Stdout)
+	case "cancel":
+		err = cmdCancel(confs, os.Stdout)
+	case "plan":
+		err = cmdPlan(confs, os.Stdout)
+	case "apply":
+		err = cmdApply(b, args)
+	case "validate":
+		err = cmdValidate(confs, os.Stdout)
+	case "loans":
+		err = cmdLoans(confs, st, os.Stdout)
+	case "report":
+		err = cmdReport(confs, st, os.Stdout)
+	case "serve":
+		err = cmdServe(b)
+	default:
+		flag.Usage()
+		log.Fatal("Unknown command: " + command)
+	}
 
-		activeWallet := strings.ToLower(conf.Bitfinex.ActiveWallet)
-		log.Println("\tDeposit wallet: " +
-			strconv.FormatFloat(balance[bitfinex.WalletKey{"deposit", activeWallet}].Amount, 'f', -1, 64) +
-			" " + activeWallet + " (swappable: " +
-			strconv.FormatFloat(balance[bitfinex.WalletKey{"deposit", activeWallet}].Available, 'f', -1, 64) +
-			" " + activeWallet + ")")
-
-		if *updateLends {
-			err = executeStrategy(conf, *dryRun)
-			if err != nil {
-				log.Println("WARNING: Failed to execute strategy: " + err.Error())
-				continue
-			}
-		}
+	if err != nil {
+		log.Println("WARNING: Command failed: " + err.Error())
 	}
+
+	if err := b.SaveState(); err != nil {
+		log.Fatal("Failed to save state file: " + err.Error())
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: BitfinexLendingBot [flags] [command] [args]
+
+Commands:
+  (none)           Show deposit balances, update lends with --updatelends
+  run              Run the strategy and update lend offers (see --dryrun)
+  plan             Show what the strategy would do right now (--out=<file> to save it)
+  apply <planfile> Execute a saved plan if the market has not drifted (see --tolerance)
+  status           Show wallet, offers, pause state and last run per account
+  offers           List active lend offers
+  balances         List all wallet balances
+  lendbook <cur>   Show the lendbook of a currency
+  cancel           Cancel lend offers: --all or --id=<offer id>
+  adopt [strategy] Let the strategy manage existing lend offers: --all or --id=<offer id>
+  validate         Check the configuration without contacting the exchange
+  loans            List active loans and their maturity schedule
+  report           Show interest earnings
+  serve            Run the strategy on an interval and serve the dashboard and control API (see --dryrun)
+
+Flags (accepted before or after the command):`)
+	flag.PrintDefaults()
 }
@@ -0,0 +1,134 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package notify
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConf ...
+type EmailConf struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	StartTLS bool
+	From     string
+	To       []string
+
+	// Event types sent immediately, defaults to run failures and critical alerts only
+	Events []string
+
+	// Daily digest
+	Digest     bool
+	DigestHour int // UTC
+}
+
+// ID ...
+func (ec EmailConf) ID() string {
+	return "email"
+}
+
+func (ec EmailConf) addr() string {
+	port := ec.Port
+	if port == 0 {
+		port = 25
+	}
+
+	return net.JoinHostPort(ec.Host, strconv.Itoa(port))
+}
+
+// Accepts returns true for the configured event types, by default failed runs and critical alerts
+func (ec EmailConf) Accepts(e Event) bool {
+	if len(ec.Events) == 0 {
+		return e.Type == EventRunFailed || e.Severity == SeverityCritical
+	}
+
+	for _, t := range ec.Events {
+		if strings.EqualFold(t, e.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Send ...
+func (ec EmailConf) Send(e Event) error {
+	subject := "[BLB] " + e.Account + ": " + e.Type
+	if e.Severity != "" {
+		subject += " (" + e.Severity + ")"
+	}
+	body := e.Message + "\r\n\r\nAccount: " + e.Account + "\r\nTime: " + e.Time.Format(time.RFC1123) + "\r\n"
+
+	return ec.SendMail(subject, body)
+}
+
+// SendMail sends a plain text message to all recipients
+func (ec EmailConf) SendMail(subject, body string) (err error) {
+	if len(ec.To) == 0 {
+		return errors.New("No email recipients configured")
+	}
+
+	c, err := smtp.Dial(ec.addr())
+	if err != nil {
+		return
+	}
+	defer c.Close()
+
+	if ec.StartTLS {
+		err = c.StartTLS(&tls.Config{ServerName: ec.Host})
+		if err != nil {
+			return errors.New("STARTTLS failed: " + err.Error())
+		}
+	}
+
+	if ec.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", ec.Username, ec.Password, ec.Host))
+		if err != nil {
+			return errors.New("SMTP authentication failed: " + err.Error())
+		}
+	}
+
+	err = c.Mail(ec.From)
+	if err != nil {
+		return
+	}
+
+	for _, to := range ec.To {
+		err = c.Rcpt(to)
+		if err != nil {
+			return
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return
+	}
+
+	msg := "From: " + ec.From + "\r\n" +
+		"To: " + strings.Join(ec.To, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		strings.Replace(body, "\n", "\r\n", -1)
+
+	_, err = w.Write([]byte(strings.Replace(msg, "\r\r\n", "\r\n", -1)))
+	if err != nil {
+		return
+	}
+
+	err = w.Close()
+	if err != nil {
+		return
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,99 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package notify
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal local SMTP server capturing received messages
+func smtpSink(t *testing.T) (host string, port int, messages chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to listen: " + err.Error())
+	}
+
+	messages = make(chan string, 10)
+
+	go func() {
+		defer l.Close()
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP sink")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+
+				var data []string
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data = append(data, l)
+				}
+
+				messages <- strings.Join(data, "")
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestEmailNotifier_RunFailed(t *testing.T) {
+	host, port, messages := smtpSink(t)
+
+	email := EmailConf{Host: host, Port: port, From: "bot@example.com", To: []string{"team@example.com"}}
+
+	// Not critical => not emailed
+	if email.Accepts(Event{Type: EventLoanFilled, Account: "acc", Message: "Filled"}) {
+		t.Error("Loan filled event accepted")
+	}
+
+	e := Event{Type: EventRunFailed, Account: "acc", Message: "Failed to execute strategy: boom"}
+	if !email.Accepts(e) {
+		t.Error("Run failed event not accepted")
+	}
+	if err := email.Send(e); err != nil {
+		t.Fatal("Failed to send: " + err.Error())
+	}
+
+	select {
+	case msg := <-messages:
+		if !strings.Contains(msg, "Subject: [BLB] acc: run_failed") || !strings.Contains(msg, "Failed to execute strategy: boom") {
+			t.Error("Sent wrong message: " + msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No message received")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+// Package notify sends bot events to webhooks and email and defines the alert rules
+package notify
+
+import (
+	"time"
+)
+
+// Event types
+const (
+	EventRunFailed  = "run_failed"
+	EventIdleFunds  = "idle_funds"
+	EventLoanFilled = "loan_filled"
+	EventFRRCross   = "frr_cross"
+	EventGuardrail  = "guardrail"
+)
+
+// DefaultDedupMinutes is used if DedupMinutes is not set
+const DefaultDedupMinutes = 60
+
+// NotificationsConf ...
+type NotificationsConf struct {
+	Webhooks           []WebhookConf
+	Email              EmailConf
+	Rules              []AlertRuleConf
+	DedupMinutes       float64
+	IdleFundsThreshold float64
+	IdleFundsMinutes   float64
+	FRRLevels          []float64
+}
+
+// Event is something worth telling the user about
+type Event struct {
+	Type     string    `json:"type"`
+	Account  string    `json:"account"`
+	Currency string    `json:"currency,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	Message  string    `json:"message"`
+	Value    float64   `json:"value,omitempty"`
+	Time     time.Time `json:"time"`
+
+	// Events with the same key are sent at most once per dedup interval
+	Key   string        `json:"-"`
+	Dedup time.Duration `json:"-"` // Overrides DedupMinutes if set
+}
+
+// Notifier is a notification sink
+type Notifier interface {
+	ID() string
+	Accepts(e Event) bool
+	Send(e Event) error
+}
+
+// Notifiers returns the configured sinks
+func (nc NotificationsConf) Notifiers() (sinks []Notifier) {
+	for _, wh := range nc.Webhooks {
+		sinks = append(sinks, wh)
+	}
+
+	if nc.Email.Host != "" {
+		sinks = append(sinks, nc.Email)
+	}
+
+	return
+}
@@ -0,0 +1,130 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package notify
+
+// Rule metrics, evaluated at the end of every account run
+const (
+	MetricIdleFunds      = "idle_funds"       // Unlent active wallet funds
+	MetricIdleValue      = "idle_value"       // Unlent active wallet funds in the reporting currency
+	MetricFRR            = "frr"              // Daily FRR of the active wallet, %
+	MetricFailedRuns     = "failed_runs"      // Consecutive failed runs
+	MetricUtilization    = "utilization"      // Lent out share of the active wallet, %
+	MetricMinDailyRate   = "min_daily_rate"   // Strategy's MinDailyLendRate, %
+	MetricStartDailyRate = "start_daily_rate" // CascadeBot's FRR + StartDailyLendRateFRRInc, %
+	MetricHighHoldMargin = "highhold_margin"  // MarginBot's HighHoldDailyRate - MinDailyLendRate, %
+)
+
+// Alert severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// EventAlert is sent by rules without an event type of their own
+const EventAlert = "alert"
+
+// AlertRuleConf ...
+type AlertRuleConf struct {
+	Name            string
+	Metric          string
+	Op              string
+	Threshold       float64
+	ForMinutes      float64
+	Severity        string
+	CooldownMinutes float64
+	Sinks           []string
+	Disabled        bool
+	Message         string
+
+	// Event type sent when the rule fires, alert by default
+	eventType string
+}
+
+// Sanity checks applied to every account unless overridden by a rule with the same name
+var defaultAlertRules = []AlertRuleConf{
+	{Name: "low_min_daily_rate", Metric: MetricMinDailyRate, Op: "<=", Threshold: 0.003, Severity: SeverityWarning, CooldownMinutes: 24 * 60,
+		Message: "Minimum daily lend rate is low"}, // 0.003% daily == 1.095% yearly
+	{Name: "high_start_daily_rate", Metric: MetricStartDailyRate, Op: ">=", Threshold: 0.5, Severity: SeverityWarning, CooldownMinutes: 60,
+		Message: "Starting daily lend rate is unusually high"},
+	{Name: "highhold_below_min_rate", Metric: MetricHighHoldMargin, Op: "<", Threshold: 0, Severity: SeverityWarning, CooldownMinutes: 24 * 60,
+		Message: "HighHold daily lend rate is lower than MinDailyLendRate"},
+}
+
+// Matches returns true if the value meets the rule's condition
+func (r AlertRuleConf) Matches(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	case "==":
+		return value == r.Threshold
+	case "!=":
+		return value != r.Threshold
+	}
+
+	return false
+}
+
+// Routes returns true if the rule's events go to the sink
+func (r AlertRuleConf) Routes(sink string) bool {
+	if len(r.Sinks) == 0 {
+		return true
+	}
+
+	return containsString(r.Sinks, sink)
+}
+
+// EventType returns the type of the events sent when the rule fires
+func (r AlertRuleConf) EventType() string {
+	if r.eventType == "" {
+		return EventAlert
+	}
+
+	return r.eventType
+}
+
+// AlertRules returns the account's rules: defaults, user rules overriding them by name
+// and the legacy idle funds notification settings
+func (nc NotificationsConf) AlertRules() (rules []AlertRuleConf) {
+	overridden := map[string]bool{}
+	for _, r := range nc.Rules {
+		overridden[r.Name] = true
+	}
+
+	for _, r := range defaultAlertRules {
+		if !overridden[r.Name] {
+			rules = append(rules, r)
+		}
+	}
+
+	rules = append(rules, nc.Rules...)
+
+	if nc.IdleFundsThreshold > 0 && !overridden[EventIdleFunds] {
+		cooldown := nc.DedupMinutes
+		if cooldown <= 0 {
+			cooldown = DefaultDedupMinutes
+		}
+
+		rules = append(rules, AlertRuleConf{Name: EventIdleFunds, Metric: MetricIdleFunds, Op: ">", Threshold: nc.IdleFundsThreshold,
+			ForMinutes: nc.IdleFundsMinutes, Severity: SeverityWarning, CooldownMinutes: cooldown, Message: "Funds left unlent",
+			eventType: EventIdleFunds})
+	}
+
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,18 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package notify
+
+import (
+	"testing"
+)
+
+func TestAlertRules_LegacyIdleFunds(t *testing.T) {
+	nc := NotificationsConf{IdleFundsThreshold: 5, IdleFundsMinutes: 10}
+
+	rules := nc.AlertRules()
+	r := rules[len(rules)-1]
+
+	if r.Name != EventIdleFunds || r.EventType() != EventIdleFunds || r.Threshold != 5 || r.ForMinutes != 10 || r.CooldownMinutes != DefaultDedupMinutes {
+		t.Errorf("Returned wrong idle funds rule %v", r)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// WebhookRetryDelay is the delay before the first webhook retry, doubled for each subsequent retry
+var WebhookRetryDelay = 2 * time.Second
+
+// WebhookConf ...
+type WebhookConf struct {
+	Name        string
+	URL         string
+	Events      []string
+	Template    string
+	ContentType string
+	Headers     map[string]string
+	Retries     int
+}
+
+var webhookFuncs = template.FuncMap{
+	// Quote a value as a JSON string for embedding into JSON templates
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ID ...
+func (wh WebhookConf) ID() string {
+	if wh.Name != "" {
+		return wh.Name
+	}
+
+	return wh.URL
+}
+
+// Accepts ...
+func (wh WebhookConf) Accepts(e Event) bool {
+	if len(wh.Events) == 0 {
+		return true
+	}
+
+	for _, t := range wh.Events {
+		if strings.EqualFold(t, e.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// render builds the request body: the raw event as JSON or the user's template
+func (wh WebhookConf) render(e Event) (body []byte, err error) {
+	if wh.Template == "" {
+		return json.Marshal(e)
+	}
+
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(wh.Template)
+	if err != nil {
+		return nil, errors.New("Invalid webhook template: " + err.Error())
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, e)
+
+	return buf.Bytes(), err
+}
+
+// Send posts the event, retrying failed attempts
+func (wh WebhookConf) Send(e Event) (err error) {
+	body, err := wh.render(e)
+	if err != nil {
+		return
+	}
+
+	contentType := wh.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	delay := WebhookRetryDelay
+
+	for attempt := 0; attempt <= wh.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		err = wh.post(client, body, contentType)
+		if err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (wh WebhookConf) post(client *http.Client, body []byte, contentType string) (err error) {
+	req, err := http.NewRequest("POST", wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("Webhook returned " + resp.Status)
+	}
+
+	return
+}
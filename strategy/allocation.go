@@ -0,0 +1,67 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"errors"
+	"math"
+)
+
+// AllocationConf assigns part of the wallet to a strategy
+type AllocationConf struct {
+	Strategy string  // MarginBot, CascadeBot, Harmonia
+	Pct      float64 // Share of the lendable funds, %
+	Amount   float64 // Fixed amount, used instead of Pct if set
+}
+
+// planAllocations runs every allocated strategy on its share of the wallet, each only
+// managing the offers it created. The offer records must be reconciled with the market first.
+func planAllocations(conf Config, m Market) (actions PlanActions, err error) {
+	owned := conf.owned()
+
+	// Shares are taken from the funds allowed to be lent
+	lendable := math.Max(0, m.Wallet.Amount-m.Reserve)
+	if conf.MaxActiveAmount >= 0 {
+		lendable = math.Min(lendable, conf.MaxActiveAmount)
+	}
+
+	idle := m.Wallet.Available
+
+	for _, a := range conf.Strategy.Allocations {
+		strategy, err := Lookup(a.Strategy)
+		if err != nil {
+			return nil, err
+		}
+
+		share := lendable * a.Pct / 100
+		if a.Amount > 0 {
+			share = math.Min(a.Amount, lendable)
+		}
+
+		sub, err := withoutOwnOffers(owned.subMarket(m, a.Strategy, share, &idle))
+		if err != nil {
+			return nil, err
+		}
+
+		conf.logger().Println("\t" + a.Strategy + " allocation: " + formatFloat(share) + " " + m.Currency + " (available: " +
+			formatFloat(sub.Wallet.Available) + ", lent: " + formatFloat(owned.strategy(a.Strategy).Lent) + ")")
+
+		sconf := conf
+		sconf.Strategy.Active = a.Strategy
+		sconf.MaxActiveAmount = -1
+
+		planActions, err := strategy(sconf, sub)
+		if err != nil {
+			return nil, errors.New(a.Strategy + " allocation: " + err.Error())
+		}
+
+		for i := range planActions {
+			planActions[i].Strategy = a.Strategy
+		}
+
+		// Never cancel the offers of other strategies
+		actions = append(actions, ownActions(planActions, sub.Offers)...)
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestPlanAllocations(t *testing.T) {
+	m := testMarket()
+	m.Lendbook.Asks[0].FRR = true
+
+	conf := Config{MaxActiveAmount: -1, Owned: &OwnedOffers{}}
+	conf.Strategy.Allocations = []AllocationConf{{Strategy: "CascadeBot", Pct: 50}, {Strategy: "MarginBot", Pct: 50}}
+	conf.Strategy.CascadeBot = CascadeBotConf{StartDailyLendRateFRRInc: 0.01, MinDailyLendRate: 0.01, ReductionIntervalMinutes: 30,
+		ExponentialDecayMult: 1, LendPeriod: 2}
+	conf.Strategy.MarginBot = MarginBotConf{MinDailyLendRate: 0.01, SpreadLend: 2, GapBottom: 10, GapTop: 100}
+
+	// Offer 1 was placed by CascadeBot, offer 2 manually
+	conf.Owned.Placed("CascadeBot", 1, 300)
+
+	actions, err := planAllocations(conf, m)
+	if err != nil {
+		t.Fatal("Failed to plan: " + err.Error())
+	}
+
+	lent := map[string]float64{}
+	for _, a := range actions {
+		if a.OfferID == 2 {
+			t.Error("Offer of another owner touched by " + a.Strategy)
+		}
+		if a.Action == ActionCancelAll {
+			t.Error("All offers cancelled by " + a.Strategy)
+		}
+		if a.Action == ActionLend {
+			lent[a.Strategy] += a.Amount.Float64()
+		}
+	}
+
+	// CascadeBot re-lends its aged offer and lends the rest of its 500 share, MarginBot gets the idle funds left
+	if lent["CascadeBot"] != 500 || lent["MarginBot"] != 200 {
+		t.Error("Returned wrong allocation (" + formatFloat(lent["CascadeBot"]) + ", " + formatFloat(lent["MarginBot"]) + ", expected: 500, 200)")
+	}
+
+	// Funds lent through an allocation count towards its share
+	conf.Strategy.Allocations[0] = AllocationConf{Strategy: "CascadeBot", Amount: 400}
+	conf.Owned.strategy("MarginBot").Lent = 500
+	m.Wallet.Amount = 1500
+	actions, _ = planAllocations(conf, m)
+
+	total := 0.0
+	for _, a := range actions {
+		if a.Strategy == "MarginBot" && a.Action == ActionLend {
+			total += a.Amount.Float64()
+		}
+	}
+	if math.Abs(total-250) > 0.00000001 {
+		t.Error("Returned wrong MarginBot amount (" + strconv.FormatFloat(total, 'f', -1, 64) + ", expected: 250)")
+	}
+}
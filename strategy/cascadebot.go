@@ -0,0 +1,168 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Strategy inspired by: https://github.com/ah3dce/cascadebot
+// Unlike the original cascadebot strategy, we start from FRR + Increment rate,
+// so that the start lending rate settings would adapt dynamically to the lendbook
+// and prevent offers from sitting unlent for long.
+
+package strategy
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// CascadeBotConf ...
+type CascadeBotConf struct {
+	StartDailyLendRateFRRInc float64
+	MinDailyLendRate         float64
+	ReductionIntervalMinutes float64
+	ReduceDailyLendRate      float64
+	ExponentialDecayMult     float64
+	LendPeriod               int
+	PeriodCurve              PeriodCurveConf
+}
+
+const (
+	cancel = iota
+	lend
+)
+
+// CascadeBotAction ...
+type CascadeBotAction struct {
+	Action             int
+	OfferID            int
+	Amount, YearlyRate exchange.Decimal
+	Period             int
+	Explain            Derivation
+}
+
+// CascadeBotActions ...
+type CascadeBotActions []CascadeBotAction
+
+func strategyCascadeBot(sconf Config, m Market) (planActions PlanActions, err error) {
+	conf := sconf.Strategy.CascadeBot
+
+	// Never guess the FRR, every rate is derived from it
+	FRR, err := m.dailyFRR()
+	if err != nil {
+		return
+	}
+
+	// Determine available funds for trading
+	available := m.Wallet.Available
+
+	// Check if we need to limit our usage
+	if sconf.MaxActiveAmount >= 0 {
+		available = math.Min(available, sconf.MaxActiveAmount)
+	}
+
+	// Keep the reserve unlent
+	available = math.Max(0, available-m.Reserve)
+
+	places := m.Info.AmountPlaces
+	actions := cascadeBotGetActions(exchange.AmountDecimal(available, places), exchange.MinLoanDecimal(m.MinLoan, places), places, FRR, m.Offers, conf)
+
+	for _, a := range actions {
+		var pa PlanAction
+		if a.Action == cancel {
+			pa = PlanAction{Action: ActionCancel, OfferID: a.OfferID}
+		} else if a.Action == lend {
+			pa = PlanAction{Action: ActionLend, Amount: a.Amount, YearlyRate: a.YearlyRate, Period: a.Period}
+		} else {
+			continue
+		}
+
+		if sconf.Explain {
+			pa.Explain = a.Explain
+		}
+
+		planActions = append(planActions, pa)
+	}
+
+	return
+}
+
+// cascadeBotGetActions decays the rates of aged offers and lends the spare funds,
+// amounts are rounded down to the given decimal places
+func cascadeBotGetActions(fundsAvailable, minLoan exchange.Decimal, places int, dailyFRR float64, activeOffers bitfinex.Offers, conf CascadeBotConf) (actions CascadeBotActions) {
+	var returned Derivation
+
+	// Update lend rates where needed
+	for _, o := range activeOffers {
+		// Check if we need to update the offer based on its timestamp
+		offerDurationMinutes := (time.Now().Unix() - int64(o.Timestamp)) / 60
+		if offerDurationMinutes >= int64(conf.ReductionIntervalMinutes) {
+			age := DerivationStep{Step: "age", Note: "offer " + strconv.Itoa(o.ID) + " open for " + strconv.FormatInt(offerDurationMinutes, 10) +
+				" min >= ReductionIntervalMinutes " + amountStr(conf.ReductionIntervalMinutes),
+				Values: map[string]float64{"OfferID": float64(o.ID), "AgeMinutes": float64(offerDurationMinutes)}}
+
+			// Cancel the offer first
+			actions = append(actions,
+				CascadeBotAction{Action: cancel, OfferID: o.ID, Explain: Derivation{age}})
+
+			// Check if there is enough amount remaining so that we can re-lend it,
+			// otherwise the offer's amount will just go back to the wallet
+			// and be lent at the "starting" daily rate
+			remaining := exchange.AmountDecimal(o.RemainingAmount, places)
+			if remaining.Cmp(minLoan) >= 0 {
+				explain := Derivation{age}
+
+				// Adjust rate only one step
+				// (e.g. to prevent offer going immediately to a minimum rate in the event of connection failure)
+				newDailyRate := o.Rate / 365
+				explain.add("current rate", rateStr(newDailyRate), map[string]float64{"DailyRate": newDailyRate})
+
+				// Linear reduction
+				newDailyRate -= conf.ReduceDailyLendRate
+				explain.add("linear decay", "- ReduceDailyLendRate "+rateStr(conf.ReduceDailyLendRate)+" = "+rateStr(newDailyRate),
+					map[string]float64{"DailyRate": newDailyRate})
+
+				// Exponential reduction
+				newDailyRate = (newDailyRate-conf.MinDailyLendRate)*conf.ExponentialDecayMult + conf.MinDailyLendRate
+				explain.add("exponential decay", "(rate - MinDailyLendRate) * ExponentialDecayMult "+amountStr(conf.ExponentialDecayMult)+
+					" + MinDailyLendRate = "+rateStr(newDailyRate), map[string]float64{"DailyRate": newDailyRate})
+
+				// Force minimum rate in case of wrong exponential decay user parameters
+				newRate := math.Max(newDailyRate, conf.MinDailyLendRate) * 365
+				if newDailyRate < conf.MinDailyLendRate {
+					explain.add("rate", "below MinDailyLendRate, clamped to "+rateStr(conf.MinDailyLendRate),
+						map[string]float64{"DailyRate": conf.MinDailyLendRate})
+				}
+
+				explain.add("amount", "re-lend remaining "+remaining.String()+" for "+strconv.Itoa(o.Period)+" days",
+					map[string]float64{"Amount": remaining.Float64()})
+
+				// Make new offer at a different rate
+				actions = append(actions, CascadeBotAction{Action: lend,
+					YearlyRate: exchange.RateDecimal(newRate), Amount: remaining, Period: explain.curvePeriod(conf.PeriodCurve, newRate/365, dailyFRR, o.Period),
+					Explain: explain})
+			} else {
+				fundsAvailable = fundsAvailable.Add(remaining)
+				returned.add("returned", "offer "+strconv.Itoa(o.ID)+" remaining "+remaining.String()+
+					" below minimum loan "+minLoan.String()+", lent again at the starting rate",
+					map[string]float64{"OfferID": float64(o.ID), "Amount": remaining.Float64()})
+			}
+		}
+	}
+
+	// Are there spare funds to offer at the "starting" daily amount?
+	if fundsAvailable.Cmp(minLoan) >= 0 {
+		startDailyRate := dailyFRR + conf.StartDailyLendRateFRRInc
+
+		explain := returned
+		explain.add("start rate", "FRR "+rateStr(dailyFRR)+" + StartDailyLendRateFRRInc "+rateStr(conf.StartDailyLendRateFRRInc)+
+			" = "+rateStr(startDailyRate), map[string]float64{"DailyFRR": dailyFRR, "DailyRate": startDailyRate})
+		explain.add("amount", "available "+fundsAvailable.String()+" for LendPeriod "+strconv.Itoa(conf.LendPeriod)+" days",
+			map[string]float64{"Amount": fundsAvailable.Float64()})
+
+		actions = append(actions, CascadeBotAction{Action: lend,
+			YearlyRate: exchange.RateDecimal(startDailyRate * 365), Amount: fundsAvailable, Period: explain.curvePeriod(conf.PeriodCurve, startDailyRate, dailyFRR, conf.LendPeriod),
+			Explain: explain})
+	}
+
+	return
+}
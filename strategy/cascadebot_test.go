@@ -0,0 +1,18 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestCascadeBot_NoFRR(t *testing.T) {
+	m := Market{Currency: "usd", Info: exchange.DefaultCurrencyInfo(), Lendbook: bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 100}}}}
+
+	if _, err := strategyCascadeBot(Config{}, m); err == nil {
+		t.Error("Planned without FRR in the lendbook")
+	}
+}
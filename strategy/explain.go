@@ -0,0 +1,53 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"log"
+	"strconv"
+)
+
+// DerivationStep is a single decision taken while deriving an offer
+type DerivationStep struct {
+	Step   string
+	Note   string
+	Values map[string]float64 `json:",omitempty"`
+}
+
+// Derivation traces how an offer's amount, rate and period were chosen
+type Derivation []DerivationStep
+
+func (d *Derivation) add(step, note string, values map[string]float64) {
+	*d = append(*d, DerivationStep{Step: step, Note: note, Values: values})
+}
+
+func (s DerivationStep) String() string {
+	return s.Step + ": " + s.Note
+}
+
+// Log prints the steps
+func (d Derivation) Log(logger *log.Logger) {
+	for _, s := range d {
+		logger.Println("\t\t" + s.String())
+	}
+}
+
+// curvePeriod applies the period curve and records whether it changed the strategy's period
+func (d *Derivation) curvePeriod(c PeriodCurveConf, dailyRate, dailyFRR float64, period int) int {
+	p := c.period(dailyRate, dailyFRR, period)
+	if p != period {
+		d.add("period curve", strconv.Itoa(period)+" -> "+strconv.Itoa(p)+" days for "+rateStr(dailyRate),
+			map[string]float64{"Period": float64(p)})
+	}
+
+	return p
+}
+
+// Number formatting used in derivation notes
+func rateStr(dailyRate float64) string {
+	return strconv.FormatFloat(dailyRate, 'g', 10, 64) + " %/day"
+}
+
+func amountStr(amount float64) string {
+	return strconv.FormatFloat(amount, 'g', 12, 64)
+}
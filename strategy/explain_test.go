@@ -0,0 +1,110 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// explainStep returns the named derivation step
+func explainStep(d Derivation, step string) (s DerivationStep, ok bool) {
+	for _, s := range d {
+		if s.Step == step {
+			return s, true
+		}
+	}
+
+	return
+}
+
+func TestMarginBotExplain(t *testing.T) {
+	conf := MarginBotConf{
+		MinDailyLendRate: 0.2,
+		SpreadLend:       2,
+		GapBottom:        0,
+		GapTop:           20,
+	}
+
+	lendbook := bitfinex.Lendbook{
+		Asks: []bitfinex.LendbookOffer{
+			bitfinex.LendbookOffer{Rate: 0.1 * 365, Amount: 5},
+			bitfinex.LendbookOffer{Rate: 0.3 * 365, Amount: 10},
+		},
+	}
+
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+	if len(loanOffers) != 2 {
+		t.Fatal("Returned wrong number of loan offers")
+	}
+
+	// First offer sits at the bottom of the book, below the minimum rate
+	depth, ok := explainStep(loanOffers[0].Explain, "depth")
+	if !ok || depth.Values["DepthIndex"] != 0 || depth.Values["DepthAmount"] != 5 || depth.Values["NextLend"] != 0 {
+		t.Errorf("Wrong depth step of first offer: %+v", depth)
+	}
+
+	if rate, _ := explainStep(loanOffers[0].Explain, "rate"); !strings.Contains(rate.Note, "clamped") {
+		t.Error("Clamping to MinDailyLendRate not explained: " + rate.Note)
+	}
+
+	// Second offer walks the book until the gap target of 10 is reached
+	depth, _ = explainStep(loanOffers[1].Explain, "depth")
+	if depth.Values["DepthIndex"] != 1 || depth.Values["DepthAmount"] != 15 || depth.Values["NextLend"] != 10 {
+		t.Errorf("Wrong depth step of second offer: %+v", depth)
+	}
+
+	if rate, _ := explainStep(loanOffers[1].Explain, "rate"); strings.Contains(rate.Note, "clamped") {
+		t.Error("Ask rate above minimum explained as clamped")
+	}
+
+	if _, ok := explainStep(loanOffers[1].Explain, "split"); !ok {
+		t.Error("Split not explained")
+	}
+}
+
+func TestCascadeBotExplain(t *testing.T) {
+	conf := CascadeBotConf{
+		StartDailyLendRateFRRInc: 0.01,
+		MinDailyLendRate:         0.05,
+		ReductionIntervalMinutes: 10,
+		ReduceDailyLendRate:      0.01,
+		ExponentialDecayMult:     0.5,
+		LendPeriod:               2,
+	}
+
+	offers := bitfinex.Offers{
+		bitfinex.Offer{ID: 1, Rate: 0.25 * 365, Period: 2, RemainingAmount: 10, Timestamp: float64(time.Now().Add(-time.Hour).Unix())},
+		bitfinex.Offer{ID: 2, Rate: 0.25 * 365, Period: 2, RemainingAmount: 1, Timestamp: float64(time.Now().Add(-time.Hour).Unix())},
+	}
+
+	actions := cascadeBotGetActions(exchange.MustDecimal("20"), exchange.MustDecimal("5"), exchange.DecimalPlaces, 0.1, offers, conf)
+	if len(actions) != 4 {
+		t.Fatal("Returned wrong number of actions")
+	}
+
+	relend := actions[1].Explain
+	if age, _ := explainStep(relend, "age"); age.Values["AgeMinutes"] < 60 {
+		t.Errorf("Wrong age step: %+v", age)
+	}
+
+	// (0.25 - 0.01 - 0.05) * 0.5 + 0.05 = 0.145
+	if s, _ := explainStep(relend, "linear decay"); s.Values["DailyRate"] < 0.2399 || s.Values["DailyRate"] > 0.2401 {
+		t.Errorf("Wrong linear decay step: %+v", s)
+	}
+	if s, _ := explainStep(relend, "exponential decay"); s.Values["DailyRate"] < 0.1449 || s.Values["DailyRate"] > 0.1451 {
+		t.Errorf("Wrong exponential decay step: %+v", s)
+	}
+
+	start := actions[3].Explain
+	if _, ok := explainStep(start, "returned"); !ok {
+		t.Error("Funds returned from a small offer not explained")
+	}
+	if s, _ := explainStep(start, "start rate"); s.Values["DailyRate"] < 0.1099 || s.Values["DailyRate"] > 0.1101 {
+		t.Errorf("Wrong start rate step: %+v", s)
+	}
+}
@@ -0,0 +1,495 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// A small expression language for the Script strategy: numbers, variables, arithmetic,
+// comparisons, logical operators and a fixed set of functions. Expressions are compiled
+// when the config is loaded, so that typos are caught before anything runs.
+
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Expr is a compiled expression, written as a JSON string
+type Expr struct {
+	src   string
+	root  exprNode
+	vars  map[string]bool // Variables used
+	funcs map[string]bool // Functions used
+}
+
+// exprEnv is what expressions are evaluated against
+type exprEnv struct {
+	vars  map[string]float64
+	asks  []bitfinex.LendbookOffer
+	lends []float64 // Daily rates of recent lends, newest first
+}
+
+type exprNode interface {
+	eval(env *exprEnv) float64
+}
+
+type exprNum float64
+
+type exprVar string
+
+type exprUnary struct {
+	op string
+	x  exprNode
+}
+
+type exprBinary struct {
+	op   string
+	x, y exprNode
+}
+
+type exprCall struct {
+	fn   exprFunc
+	args []exprNode
+}
+
+// exprFunc ...
+type exprFunc struct {
+	minArgs, maxArgs int // maxArgs -1 for any number
+	call             func(env *exprEnv, args []float64) float64
+}
+
+var exprFuncs = map[string]exprFunc{
+	"min":   {1, -1, func(env *exprEnv, a []float64) float64 { return foldFloats(a, math.Min) }},
+	"max":   {1, -1, func(env *exprEnv, a []float64) float64 { return foldFloats(a, math.Max) }},
+	"abs":   {1, 1, func(env *exprEnv, a []float64) float64 { return math.Abs(a[0]) }},
+	"floor": {1, 1, func(env *exprEnv, a []float64) float64 { return math.Floor(a[0]) }},
+	"ceil":  {1, 1, func(env *exprEnv, a []float64) float64 { return math.Ceil(a[0]) }},
+	"round": {1, 1, func(env *exprEnv, a []float64) float64 { return math.Floor(a[0] + 0.5) }},
+	"clamp": {3, 3, func(env *exprEnv, a []float64) float64 { return math.Max(a[1], math.Min(a[0], a[2])) }},
+	"if": {3, 3, func(env *exprEnv, a []float64) float64 {
+		if a[0] != 0 {
+			return a[1]
+		}
+		return a[2]
+	}},
+
+	// Daily rate of the ask where the cumulative lendbook depth reaches the amount
+	"depth": {1, 1, func(env *exprEnv, a []float64) float64 { return env.depthRate(a[0]) }},
+
+	// Daily rate of the ask where the cumulative depth reaches the share (%) of the total depth
+	"depthpct": {1, 1, func(env *exprEnv, a []float64) float64 {
+		total := 0.0
+		for _, o := range env.asks {
+			total += o.Amount
+		}
+		return env.depthRate(total * a[0] / 100)
+	}},
+
+	// Percentile (%) of the daily rates of the last n lends
+	"lends": {2, 2, func(env *exprEnv, a []float64) float64 { return env.lendsPercentile(a[0], int(a[1])) }},
+}
+
+func foldFloats(values []float64, f func(x, y float64) float64) float64 {
+	result := values[0]
+	for _, v := range values[1:] {
+		result = f(result, v)
+	}
+
+	return result
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func (env *exprEnv) depthRate(amount float64) float64 {
+	if len(env.asks) == 0 {
+		return math.NaN()
+	}
+
+	depth := 0.0
+	for _, o := range env.asks {
+		depth += o.Amount
+		if depth >= amount {
+			return o.Rate / 365
+		}
+	}
+
+	return env.asks[len(env.asks)-1].Rate / 365
+}
+
+func (env *exprEnv) lendsPercentile(pct float64, n int) float64 {
+	if n > len(env.lends) {
+		n = len(env.lends)
+	}
+	if n <= 0 {
+		return math.NaN()
+	}
+
+	rates := append([]float64{}, env.lends[:n]...)
+	sort.Float64s(rates)
+
+	// Nearest rank
+	rank := int(math.Ceil(pct / 100 * float64(n)))
+	if rank < 1 {
+		rank = 1
+	} else if rank > n {
+		rank = n
+	}
+
+	return rates[rank-1]
+}
+
+func (n exprNum) eval(env *exprEnv) float64 { return float64(n) }
+
+func (v exprVar) eval(env *exprEnv) float64 {
+	value, ok := env.vars[string(v)]
+	if !ok {
+		return math.NaN()
+	}
+
+	return value
+}
+
+func (u exprUnary) eval(env *exprEnv) float64 {
+	x := u.x.eval(env)
+	if u.op == "!" {
+		return boolFloat(x == 0)
+	}
+
+	return -x
+}
+
+func (b exprBinary) eval(env *exprEnv) float64 {
+	x, y := b.x.eval(env), b.y.eval(env)
+
+	switch b.op {
+	case "+":
+		return x + y
+	case "-":
+		return x - y
+	case "*":
+		return x * y
+	case "/":
+		return x / y
+	case "<":
+		return boolFloat(x < y)
+	case ">":
+		return boolFloat(x > y)
+	case "<=":
+		return boolFloat(x <= y)
+	case ">=":
+		return boolFloat(x >= y)
+	case "==":
+		return boolFloat(x == y)
+	case "!=":
+		return boolFloat(x != y)
+	case "&&":
+		return boolFloat(x != 0 && y != 0)
+	case "||":
+		return boolFloat(x != 0 || y != 0)
+	}
+
+	return math.NaN()
+}
+
+func (c exprCall) eval(env *exprEnv) float64 {
+	args := make([]float64, len(c.args))
+	for i, a := range c.args {
+		args[i] = a.eval(env)
+	}
+
+	return c.fn.call(env, args)
+}
+
+// compileExpr parses the expression, checking the function names and argument counts
+func compileExpr(src string) (e Expr, err error) {
+	p := &exprParser{src: src, vars: map[string]bool{}, funcs: map[string]bool{}}
+
+	err = p.next()
+	if err != nil {
+		return
+	}
+
+	root, err := p.parseBinary(0)
+	if err != nil {
+		return
+	}
+	if p.tok != "" {
+		return e, p.errorf("unexpected \"" + p.tok + "\"")
+	}
+
+	return Expr{src: src, root: root, vars: p.vars, funcs: p.funcs}, nil
+}
+
+func (e Expr) String() string {
+	return e.src
+}
+
+// Empty returns true if no expression is set
+func (e Expr) Empty() bool {
+	return e.root == nil
+}
+
+// uses returns true if the expression calls the function
+func (e Expr) uses(fn string) bool {
+	return e.funcs[fn]
+}
+
+// unknownVars returns the variables used that are not in the list, sorted
+func (e Expr) unknownVars(known []string) (unknown []string) {
+	for v := range e.vars {
+		if !containsString(known, v) {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Strings(unknown)
+
+	return
+}
+
+// eval evaluates the expression, failing if the result is not a finite number
+func (e Expr) eval(env *exprEnv) (float64, error) {
+	v := e.root.eval(env)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return v, errors.New("Expression \"" + e.src + "\" is not a number (" + formatFloat(v) + ")")
+	}
+
+	return v, nil
+}
+
+// MarshalJSON ...
+func (e Expr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.src)
+}
+
+// UnmarshalJSON compiles the expression
+func (e *Expr) UnmarshalJSON(data []byte) (err error) {
+	var src string
+	err = json.Unmarshal(data, &src)
+	if err != nil {
+		return
+	}
+
+	if strings.TrimSpace(src) == "" {
+		*e = Expr{}
+		return
+	}
+
+	*e, err = compileExpr(src)
+	return
+}
+
+// Binary operators by precedence, lowest first
+var exprPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"<", ">", "<=", ">=", "==", "!="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+type exprParser struct {
+	src   string
+	pos   int    // After the current token
+	start int    // Of the current token
+	tok   string // Current token, empty at the end
+	num   bool   // Current token is a number
+
+	vars, funcs map[string]bool
+}
+
+func (p *exprParser) errorf(msg string) error {
+	return errors.New("Expression \"" + p.src + "\" at position " + strconv.Itoa(p.start+1) + ": " + msg)
+}
+
+// next reads the next token
+func (p *exprParser) next() error {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+
+	p.start, p.tok, p.num = p.pos, "", false
+	if p.pos >= len(p.src) {
+		return nil
+	}
+
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	isLetter := func(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
+
+	c := p.src[p.pos]
+	switch {
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.num = true
+	case isLetter(c):
+		for p.pos < len(p.src) && (isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+	case p.pos+1 < len(p.src) && containsString([]string{"<=", ">=", "==", "!=", "&&", "||"}, p.src[p.pos:p.pos+2]):
+		p.pos += 2
+	case strings.IndexByte("+-*/()<>!,", c) >= 0:
+		p.pos++
+	default:
+		return p.errorf("unexpected character \"" + string(c) + "\"")
+	}
+
+	p.tok = p.src[p.start:p.pos]
+
+	return nil
+}
+
+// parseBinary parses operators of the precedence level and higher
+func (p *exprParser) parseBinary(level int) (node exprNode, err error) {
+	if level == len(exprPrecedence) {
+		return p.parseUnary()
+	}
+
+	node, err = p.parseBinary(level + 1)
+	if err != nil {
+		return
+	}
+
+	for !p.num && containsString(exprPrecedence[level], p.tok) {
+		op := p.tok
+		err = p.next()
+		if err != nil {
+			return
+		}
+
+		var y exprNode
+		y, err = p.parseBinary(level + 1)
+		if err != nil {
+			return
+		}
+
+		node = exprBinary{op: op, x: node, y: y}
+	}
+
+	return
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if !p.num && (p.tok == "-" || p.tok == "!") {
+		op := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return exprUnary{op: op, x: x}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (node exprNode, err error) {
+	tok := p.tok
+
+	switch {
+	case tok == "":
+		return nil, p.errorf("unexpected end")
+	case p.num:
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number \"" + tok + "\"")
+		}
+
+		return exprNum(f), p.next()
+	case tok == "(":
+		if err = p.next(); err != nil {
+			return
+		}
+
+		node, err = p.parseBinary(0)
+		if err != nil {
+			return
+		}
+		if p.tok != ")" {
+			return nil, p.errorf("missing \")\"")
+		}
+
+		return node, p.next()
+	case strings.IndexByte("+-*/()<>!,=&|", tok[0]) >= 0:
+		return nil, p.errorf("unexpected \"" + tok + "\"")
+	}
+
+	// Variable or function call
+	if err = p.next(); err != nil {
+		return
+	}
+	if p.tok != "(" {
+		p.vars[tok] = true
+		return exprVar(tok), nil
+	}
+
+	fn, ok := exprFuncs[tok]
+	if !ok {
+		return nil, p.errorf("unknown function \"" + tok + "\"")
+	}
+	p.funcs[tok] = true
+
+	var args []exprNode
+	if err = p.next(); err != nil {
+		return
+	}
+	for p.tok != ")" {
+		if p.tok == "" {
+			return nil, p.errorf("missing \")\"")
+		}
+		if len(args) > 0 {
+			if p.tok != "," {
+				return nil, p.errorf("expected \",\" or \")\"")
+			}
+			if err = p.next(); err != nil {
+				return
+			}
+		}
+
+		var arg exprNode
+		arg, err = p.parseBinary(0)
+		if err != nil {
+			return
+		}
+		args = append(args, arg)
+	}
+
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, p.errorf(tok + "() takes " + exprArity(fn) + " arguments, got " + strconv.Itoa(len(args)))
+	}
+
+	return exprCall{fn: fn, args: args}, p.next()
+}
+
+func exprArity(fn exprFunc) string {
+	switch {
+	case fn.maxArgs < 0:
+		return "at least " + strconv.Itoa(fn.minArgs)
+	case fn.minArgs == fn.maxArgs:
+		return strconv.Itoa(fn.minArgs)
+	}
+
+	return strconv.Itoa(fn.minArgs) + " to " + strconv.Itoa(fn.maxArgs)
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
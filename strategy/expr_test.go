@@ -0,0 +1,116 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestExpr_Eval(t *testing.T) {
+	env := &exprEnv{
+		vars:  map[string]float64{"FRR": 0.05, "Available": 1000, "I": 2},
+		asks:  []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 100}, {Rate: 73, Amount: 100}, {Rate: 109.5, Amount: 200}},
+		lends: []float64{0.1, 0.3, 0.2, 0.4, 0.5},
+	}
+
+	tests := []struct {
+		src   string
+		value float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"8 / 4 / 2", 1},
+		{"-FRR + 1", 0.95},
+		{"FRR + 0.002", 0.052},
+		{"Available / 4 > 200", 1},
+		{"1 < 2 && 2 < 1 || !0", 1},
+		{"I == 2", 1},
+		{"I != 2", 0},
+		{"max(FRR + 0.002, 0.01, 0.06)", 0.06},
+		{"min(3, floor(Available / 300))", 3},
+		{"if(I >= 2, 30, 2)", 30},
+		{"clamp(FRR * 10, 0.1, 0.3)", 0.3},
+		{"abs(-2) + ceil(0.2) + round(2.5)", 6},
+		{"depth(150)", 0.2},
+		{"depth(10000)", 0.3},
+		{"depthpct(50)", 0.2},
+		{"lends(75, 4)", 0.3},
+		{"lends(50, 50)", 0.3},
+	}
+
+	for _, tt := range tests {
+		e, err := compileExpr(tt.src)
+		if err != nil {
+			t.Error("Failed to compile \"" + tt.src + "\": " + err.Error())
+			continue
+		}
+
+		value, err := e.eval(env)
+		if err != nil || math.Abs(value-tt.value) > 0.0000000001 {
+			t.Error("Returned wrong value for \"" + tt.src + "\" (" + strconv.FormatFloat(value, 'f', -1, 64) +
+				", expected: " + strconv.FormatFloat(tt.value, 'f', -1, 64) + ")")
+		}
+	}
+
+	// Not a number
+	for _, src := range []string{"1 / 0", "lends(50, 0)", "Unknown"} {
+		e, _ := compileExpr(src)
+		if _, err := e.eval(env); err == nil {
+			t.Error("Invalid result of \"" + src + "\" accepted")
+		}
+	}
+}
+
+func TestCompileExpr_Errors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"1 +", "unexpected end"},
+		{"(1 + 2", "missing \")\""},
+		{"1 2", "unexpected \"2\""},
+		{"1 $ 2", "unexpected character"},
+		{"median(1)", "unknown function"},
+		{"max()", "takes at least 1 arguments"},
+		{"lends(1)", "takes 2 arguments"},
+		{"min(1 2)", "expected \",\""},
+		{"1..2", "invalid number"},
+		{"* 2", "unexpected \"*\""},
+	}
+
+	for _, tt := range tests {
+		_, err := compileExpr(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Error("Returned wrong error for \"" + tt.src + "\" (" + errString(err) + ", expected: " + tt.err + ")")
+		}
+	}
+}
+
+func TestExpr_JSON(t *testing.T) {
+	var e Expr
+	if err := json.Unmarshal([]byte(`"max(FRR, lends(75, 50)) + I * 0.01"`), &e); err != nil {
+		t.Fatal("Failed to parse: " + err.Error())
+	}
+
+	if !e.uses("lends") || e.uses("depth") {
+		t.Error("Returned wrong functions used")
+	}
+	if unknown := e.unknownVars([]string{"FRR"}); strings.Join(unknown, ",") != "I" {
+		t.Error("Returned wrong unknown variables (" + strings.Join(unknown, ",") + ", expected: I)")
+	}
+
+	data, _ := json.Marshal(e)
+	if string(data) != `"max(FRR, lends(75, 50)) + I * 0.01"` {
+		t.Error("Returned wrong JSON (" + string(data) + ")")
+	}
+
+	if err := json.Unmarshal([]byte(`"max(FRR"`), &e); err == nil {
+		t.Error("Invalid expression accepted")
+	}
+}
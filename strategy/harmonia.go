@@ -0,0 +1,141 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Strategy inspired by: https://github.com/evdubs/Harmonia
+// Offer rates are picked at fixed fractions of the total outstanding ask volume
+// in the lendbook, so that the ladder follows the shape of the book rather than
+// absolute volume gaps. Offers priced well above FRR are locked in for longer.
+
+package strategy
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// HarmoniaConf ...
+type HarmoniaConf struct {
+	MinDailyLendRate  float64
+	SpreadLend        int
+	DepthPctBottom    float64
+	DepthPctTop       float64
+	LongPeriodFRRMult float64
+	PeriodCurve       PeriodCurveConf
+}
+
+// HarmoniaLoanOffer ...
+type HarmoniaLoanOffer struct {
+	Amount, Rate exchange.Decimal
+	Period       int
+}
+
+// HarmoniaLoanOffers ...
+type HarmoniaLoanOffers []HarmoniaLoanOffer
+
+func strategyHarmonia(sconf Config, m Market) (planActions PlanActions, err error) {
+	conf := sconf.Strategy.Harmonia
+
+	// Do sanity check: Is the depth range valid?
+	if conf.DepthPctBottom < 0 || conf.DepthPctTop > 100 || conf.DepthPctBottom > conf.DepthPctTop {
+		return nil, errors.New("Invalid depth range [" + strconv.FormatFloat(conf.DepthPctBottom, 'f', -1, 64) + "%, " +
+			strconv.FormatFloat(conf.DepthPctTop, 'f', -1, 64) + "%]")
+	}
+
+	// Determine available funds for trading (all active offers get cancelled first)
+	available := m.Wallet.Available + m.Offered()
+
+	// Check if we need to limit our usage
+	if sconf.MaxActiveAmount >= 0 {
+		available = math.Min(available, math.Min(available+sconf.MaxActiveAmount-m.Wallet.Amount, sconf.MaxActiveAmount))
+	}
+
+	// Keep the reserve unlent
+	available = math.Max(0, available-m.Reserve)
+
+	places := m.Info.AmountPlaces
+	loanOffers := harmoniaGetLoanOffers(exchange.AmountDecimal(available, places), exchange.MinLoanDecimal(m.MinLoan, places), places, m.Lendbook, conf)
+
+	// Cancel all active offers before placing the new ones
+	planActions = append(planActions, PlanAction{Action: ActionCancelAll})
+
+	for _, o := range loanOffers {
+		planActions = append(planActions, PlanAction{Action: ActionLend, Amount: o.Amount, YearlyRate: o.Rate, Period: o.Period})
+	}
+
+	return
+}
+
+// harmoniaGetLoanOffers splits the funds into offers, amounts are rounded down to the given decimal places
+func harmoniaGetLoanOffers(fundsAvailable, minLoan exchange.Decimal, places int, lendbook bitfinex.Lendbook, conf HarmoniaConf) (loanOffers HarmoniaLoanOffers) {
+	// Sanity check: if it's less than minLoan or the book is empty we have nothing to do
+	if fundsAvailable.Cmp(minLoan) < 0 || len(lendbook.Asks) == 0 {
+		return
+	}
+
+	// How many splits do we want?
+	numSplits := conf.SpreadLend
+
+	var amtEach exchange.Decimal
+	for ; numSplits > 0; numSplits-- {
+		// Round down to the currency precision, so that the splits never exceed the funds
+		amtEach = fundsAvailable.DivInt(numSplits, places, exchange.RoundDown)
+
+		// Minimize number of splits in case we cannot split in the number of required parts
+		if amtEach.Cmp(minLoan) >= 0 {
+			break
+		}
+	}
+
+	// Sanity check: is there any positive number of splits possible?
+	if numSplits <= 0 {
+		return
+	}
+
+	// Total outstanding ask volume
+	totalDepth := 0.0
+	for _, o := range lendbook.Asks {
+		totalDepth += o.Amount
+	}
+
+	dailyFRR := exchange.LendbookDailyFRR(lendbook)
+
+	depthStep := 0.0
+	if numSplits > 1 {
+		depthStep = (conf.DepthPctTop - conf.DepthPctBottom) / float64(numSplits-1)
+	}
+
+	// Keep running total
+	depthIndex := 0
+	depthAmount := lendbook.Asks[depthIndex].Amount
+
+	for i := 0; i < numSplits; i++ {
+		nextLend := totalDepth * (conf.DepthPctBottom + depthStep*float64(i)) / 100
+
+		// Go trough lendbook until we meet our "nextLend" limit
+		for depthAmount < nextLend && depthIndex < len(lendbook.Asks)-1 {
+			depthIndex++
+			depthAmount += lendbook.Asks[depthIndex].Amount
+		}
+
+		tmp := HarmoniaLoanOffer{Amount: amtEach}
+
+		// Make sure the depth rate is higher than the minimum lend rate...
+		rate := math.Max(lendbook.Asks[depthIndex].Rate, conf.MinDailyLendRate*365)
+		tmp.Rate = exchange.RateDecimal(rate)
+
+		// Lock in offers which are well above FRR for as long as possible
+		if conf.LongPeriodFRRMult > 0 && dailyFRR > 0 && rate >= dailyFRR*365*conf.LongPeriodFRRMult {
+			tmp.Period = 30
+		} else {
+			tmp.Period = 2
+		}
+
+		tmp.Period = conf.PeriodCurve.period(rate/365, dailyFRR, tmp.Period)
+
+		loanOffers = append(loanOffers, tmp)
+	}
+
+	return
+}
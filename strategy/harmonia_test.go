@@ -0,0 +1,146 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestHarmoniaGetLoanOffers_MinDailyLendRate(t *testing.T) {
+	conf := HarmoniaConf{
+		MinDailyLendRate: 1, // 365 APR
+		SpreadLend:       1, // Generate only one offer
+		DepthPctBottom:   0, // Start from the lowest offer in the lendbook
+	}
+
+	// All offers in landbook are below our minimum of "MinDailyLendRate"
+	lendbook := bitfinex.Lendbook{
+		Asks: []bitfinex.LendbookOffer{
+			bitfinex.LendbookOffer{Rate: 0.1 * 365, Amount: 1},
+			bitfinex.LendbookOffer{Rate: 0.2 * 365, Amount: 1},
+			bitfinex.LendbookOffer{Rate: 0.3 * 365, Amount: 1},
+		},
+	}
+
+	// Balance 100, no min for offers
+	loanOffers := harmoniaGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if only one offer was returned
+	if len(loanOffers) != 1 {
+		t.Fatal("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 1)")
+	}
+
+	// Check for expected rate
+	if loanOffers[0].Rate != exchange.MustDecimal("365") {
+		t.Error("Returned wrong minimum offer rate (" + loanOffers[0].Rate.String() + " APR, expected: 365 APR)")
+	}
+
+	// Check for expected period
+	if loanOffers[0].Period != 2 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[0].Period) + " days, expected: 2 days)")
+	}
+
+	// Check for expected amount
+	if loanOffers[0].Amount != exchange.MustDecimal("100") {
+		t.Error("Returned wrong offer amount (" + loanOffers[0].Amount.String() + " , expected: 100)")
+	}
+
+	// Available balance 100, 101 required minimum
+	loanOffers = harmoniaGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("101"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if none offers were returned
+	if len(loanOffers) != 0 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 0)")
+	}
+}
+
+func TestHarmoniaGetLoanOffers_EmptyLendbook(t *testing.T) {
+	conf := HarmoniaConf{
+		SpreadLend:  3,
+		DepthPctTop: 100,
+	}
+
+	// Nothing to price the offers against
+	loanOffers := harmoniaGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, bitfinex.Lendbook{}, conf)
+
+	if len(loanOffers) != 0 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 0)")
+	}
+}
+
+func TestHarmoniaGetLoanOffers_LongPeriodFRRMult(t *testing.T) {
+	conf := HarmoniaConf{
+		MinDailyLendRate:  0.1,
+		SpreadLend:        2,   // Generate two offers
+		DepthPctBottom:    0,   // First offer at the lowest ask
+		DepthPctTop:       100, // Last offer at the highest ask
+		LongPeriodFRRMult: 1.5, // Lock in offers at >= 1.5 x FRR
+	}
+
+	lendbook := bitfinex.Lendbook{
+		Asks: []bitfinex.LendbookOffer{
+			bitfinex.LendbookOffer{Rate: 1 * 365, Amount: 1, FRR: true}, // FRR == 1 % / day
+			bitfinex.LendbookOffer{Rate: 2 * 365, Amount: 1},
+		},
+	}
+
+	// Balance 100, no min for offers
+	loanOffers := harmoniaGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	if len(loanOffers) != 2 {
+		t.Fatal("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 2)")
+	}
+
+	// Offer at FRR is placed for a short period
+	if loanOffers[0].Period != 2 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[0].Period) + " days, expected: 2 days)")
+	}
+
+	// Offer at 2 x FRR is locked in
+	if loanOffers[1].Period != 30 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[1].Period) + " days, expected: 30 days)")
+	}
+}
+
+func TestHarmoniaGetLoanOffers_General(t *testing.T) {
+	// Fill lendbook with asks for: 0.1, 0.2...5.0 % daily, 1 unit each (50 total)
+	asks := []bitfinex.LendbookOffer{}
+	for i := 1; i <= 50; i++ {
+		asks = append(asks, bitfinex.LendbookOffer{Rate: (0.1 * float64(i)) * 365, Amount: 1})
+	}
+
+	lendbook := bitfinex.Lendbook{Asks: asks}
+
+	conf := HarmoniaConf{
+		SpreadLend:     4,  // Split into 4 offers
+		DepthPctBottom: 10, // First offer after 10% of the book (5 units)
+		DepthPctTop:    70, // Last offer after 70% of the book (35 units)
+
+		MinDailyLendRate: 0.6, // First potential offer (0.5 % / day) is below minimum (0.6 % / day)
+	}
+
+	// Available balance 100, minimum 30 per offer => only 3 offers fit
+	loanOffers := harmoniaGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("30"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Populate expected offers (depth at 10%, 40%, 70% => 5, 20, 35 units)
+	expectedOffers := HarmoniaLoanOffers{
+		HarmoniaLoanOffer{Amount: exchange.MustDecimal("33.33333333"), Rate: exchange.MustDecimal("219"), Period: 2}, // Offer which has a below minimum rate that was increased
+		HarmoniaLoanOffer{Amount: exchange.MustDecimal("33.33333333"), Rate: exchange.MustDecimal("730"), Period: 2},
+		HarmoniaLoanOffer{Amount: exchange.MustDecimal("33.33333333"), Rate: exchange.MustDecimal("1277.5"), Period: 2},
+	}
+
+	if len(loanOffers) != len(expectedOffers) {
+		t.Fatal("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: " + strconv.Itoa(len(expectedOffers)) + ")")
+	}
+
+	for i, eo := range expectedOffers {
+		lo := loanOffers[i]
+		if eo.Period != lo.Period || eo.Rate != lo.Rate || eo.Amount != lo.Amount {
+			t.Errorf("Returned wrong loan offer %v (expected: %v)", lo, eo)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Strategy inspired by: https://github.com/HFenter/MarginBot
+
+package strategy
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// MarginBotConf ...
+type MarginBotConf struct {
+	MinDailyLendRate        float64
+	SpreadLend              int
+	GapBottom               float64
+	GapTop                  float64
+	ThirtyDayDailyThreshold float64
+	HighHoldDailyRate       float64
+	HighHoldAmount          float64
+	PeriodCurve             PeriodCurveConf
+}
+
+// MarginBotLoanOffer ...
+type MarginBotLoanOffer struct {
+	Amount, Rate exchange.Decimal
+	Period       int
+	Explain      Derivation
+}
+
+// MarginBotLoanOffers ...
+type MarginBotLoanOffers []MarginBotLoanOffer
+
+func strategyMarginBot(sconf Config, m Market) (planActions PlanActions, err error) {
+	conf := sconf.Strategy.MarginBot
+
+	// Determine available funds for trading (all active offers get cancelled first)
+	available := m.Wallet.Available + m.Offered()
+
+	// Check if we need to limit our usage
+	if sconf.MaxActiveAmount >= 0 {
+		available = math.Min(available, math.Min(available+sconf.MaxActiveAmount-m.Wallet.Amount, sconf.MaxActiveAmount))
+
+	}
+
+	// Keep the reserve unlent
+	available = math.Max(0, available-m.Reserve)
+
+	places := m.Info.AmountPlaces
+	loanOffers := marginBotGetLoanOffers(exchange.AmountDecimal(available, places), exchange.MinLoanDecimal(m.MinLoan, places), places, m.Lendbook, conf)
+
+	// Cancel all active offers before placing the new ones
+	planActions = append(planActions, PlanAction{Action: ActionCancelAll})
+
+	for _, o := range loanOffers {
+		a := PlanAction{Action: ActionLend, Amount: o.Amount, YearlyRate: o.Rate, Period: o.Period}
+		if sconf.Explain {
+			a.Explain = o.Explain
+		}
+
+		planActions = append(planActions, a)
+	}
+
+	return
+}
+
+// marginBotGetLoanOffers splits the funds into offers, amounts are rounded down to the given decimal places
+func marginBotGetLoanOffers(fundsAvailable, minLoan exchange.Decimal, places int, lendbook bitfinex.Lendbook, conf MarginBotConf) (loanOffers MarginBotLoanOffers) {
+	// Sanity check: if it's less than minLonad we have nothing to do
+	if fundsAvailable.Cmp(minLoan) < 0 {
+		return
+	}
+
+	splitFundsAvailable := fundsAvailable
+	dailyFRR := exchange.LendbookDailyFRR(lendbook)
+
+	// HighHold is a special case, substract from the available amount
+	// HighHoldAmount = 0 => No HighHold required
+	if highHoldAmount := exchange.DecimalFromFloat(conf.HighHoldAmount).Round(places, exchange.RoundDown); highHoldAmount.Cmp(minLoan) > 0 {
+		tmp := MarginBotLoanOffer{
+			Amount: exchange.MinDecimal(fundsAvailable, highHoldAmount), // Make sure we have required balance to make HighHold offer
+			Rate:   exchange.RateDecimal(conf.HighHoldDailyRate * 365),
+		}
+
+		tmp.Explain.add("highhold", "min(available "+fundsAvailable.String()+", HighHoldAmount "+highHoldAmount.String()+") = "+
+			tmp.Amount.String()+" at HighHoldDailyRate "+rateStr(conf.HighHoldDailyRate)+" for 30 days",
+			map[string]float64{"Available": fundsAvailable.Float64(), "HighHoldAmount": highHoldAmount.Float64(), "Amount": tmp.Amount.Float64(), "DailyRate": conf.HighHoldDailyRate})
+
+		// Offer HighHold rate for 30 days unless the curve says otherwise
+		tmp.Period = tmp.Explain.curvePeriod(conf.PeriodCurve, conf.HighHoldDailyRate, dailyFRR, 30)
+
+		splitFundsAvailable = splitFundsAvailable.Sub(tmp.Amount)
+		loanOffers = append(loanOffers, tmp)
+	}
+
+	// How many splits do we want?
+	numSplits := conf.SpreadLend
+
+	// is there anything left after the highhold?  if so, lets split it up
+	if numSplits > 0 && splitFundsAvailable.Cmp(minLoan) >= 0 {
+
+		// Round down to the currency precision, so that the splits never exceed the funds
+		amtEach := splitFundsAvailable.DivInt(numSplits, places, exchange.RoundDown)
+
+		// Minimize number of splits in case we cannot split in the number of required parts
+		for numSplits > 0 && amtEach.Cmp(minLoan) <= 0 {
+			numSplits--
+			if numSplits > 0 {
+				amtEach = splitFundsAvailable.DivInt(numSplits, places, exchange.RoundDown)
+			}
+		}
+
+		// Sanity check: is there any positive number of splits possible, and a book to place them in?
+		if numSplits <= 0 || len(lendbook.Asks) == 0 {
+			return
+		}
+
+		split := DerivationStep{Step: "split", Note: splitFundsAvailable.String() + " split into " + strconv.Itoa(numSplits) +
+			" offers of " + amtEach.String() + " (SpreadLend " + strconv.Itoa(conf.SpreadLend) + ")",
+			Values: map[string]float64{"Funds": splitFundsAvailable.Float64(), "Splits": float64(numSplits), "Amount": amtEach.Float64()}}
+
+		gapClimb := (conf.GapTop - conf.GapBottom) / float64(numSplits)
+		nextLend := conf.GapBottom
+
+		// Keep running total
+		depthIndex := 0
+		depthAmount := lendbook.Asks[depthIndex].Amount
+
+		for numSplits > 0 {
+			// Go trough lendbook until we meet our "nextLend" limit
+			for depthAmount < nextLend && depthIndex < len(lendbook.Asks)-1 {
+				depthIndex++
+				depthAmount += lendbook.Asks[depthIndex].Amount
+			}
+
+			tmp := MarginBotLoanOffer{}
+			tmp.Amount = amtEach
+			tmp.Explain = Derivation{split}
+
+			askDailyRate := lendbook.Asks[depthIndex].Rate / 365
+			reached := "reached"
+			if depthAmount < nextLend {
+				reached = "end of lendbook, not reached"
+			}
+			tmp.Explain.add("depth", "ask #"+strconv.Itoa(depthIndex)+" at "+rateStr(askDailyRate)+": cumulative depth "+
+				amountStr(depthAmount)+", gap target "+amountStr(nextLend)+" "+reached,
+				map[string]float64{"DepthIndex": float64(depthIndex), "DepthAmount": depthAmount, "NextLend": nextLend, "AskDailyRate": askDailyRate})
+
+			// Make sure the gap setting rate is higher than the minimum lend rate...
+			if lendbook.Asks[depthIndex].Rate < conf.MinDailyLendRate*365 {
+				tmp.Rate = exchange.RateDecimal(conf.MinDailyLendRate * 365)
+				tmp.Explain.add("rate", "ask rate below MinDailyLendRate, clamped to "+rateStr(conf.MinDailyLendRate),
+					map[string]float64{"DailyRate": conf.MinDailyLendRate})
+			} else {
+				tmp.Rate = exchange.RateDecimal(lendbook.Asks[depthIndex].Rate)
+				tmp.Explain.add("rate", "ask rate "+rateStr(askDailyRate)+" (MinDailyLendRate "+rateStr(conf.MinDailyLendRate)+")",
+					map[string]float64{"DailyRate": askDailyRate})
+			}
+
+			// Are there loans that have high rate? If yes, lend them for as long as possible
+			if conf.ThirtyDayDailyThreshold > 0 && lendbook.Asks[depthIndex].Rate >= conf.ThirtyDayDailyThreshold*365 {
+				tmp.Period = 30
+				tmp.Explain.add("period", "ask rate at or above ThirtyDayDailyThreshold "+rateStr(conf.ThirtyDayDailyThreshold)+", 30 days", nil)
+			} else if conf.ThirtyDayDailyThreshold > 0 {
+				tmp.Period = 2
+				tmp.Explain.add("period", "ask rate below ThirtyDayDailyThreshold "+rateStr(conf.ThirtyDayDailyThreshold)+", 2 days", nil)
+			} else {
+				tmp.Period = 2
+				tmp.Explain.add("period", "ThirtyDayDailyThreshold disabled, 2 days", nil)
+			}
+
+			tmp.Period = tmp.Explain.curvePeriod(conf.PeriodCurve, tmp.Rate.Float64()/365, dailyFRR, tmp.Period)
+
+			loanOffers = append(loanOffers, tmp)
+			nextLend += gapClimb
+			numSplits--
+		}
+
+	}
+
+	return
+}
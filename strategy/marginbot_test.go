@@ -0,0 +1,282 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestMarginBotGetLoanOffers_MinDailyLendRate(t *testing.T) {
+	conf := MarginBotConf{
+		MinDailyLendRate: 1, // 365 APR
+		SpreadLend:       1, // Generate only one offer
+		GapBottom:        0, // Start from the lowest offer in the lendbook
+	}
+
+	// All offers in landbook are below our minimum of "MinDailyLendRate"
+	lendbook := bitfinex.Lendbook{
+		Asks: []bitfinex.LendbookOffer{
+			bitfinex.LendbookOffer{Rate: 0.1 * 365},
+			bitfinex.LendbookOffer{Rate: 0.2 * 365},
+			bitfinex.LendbookOffer{Rate: 0.3 * 365},
+		},
+	}
+
+	// Balance 100, no min for offers
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if only one offer was returned
+	if len(loanOffers) != 1 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 1)")
+	}
+
+	// Check for expected rate
+	if loanOffers[0].Rate != exchange.MustDecimal("365") {
+		t.Error("Returned wrong minimum offer rate (" + loanOffers[0].Rate.String() + " APR, expected: 365 APR)")
+	}
+
+	// Check for expected period
+	if loanOffers[0].Period != 2 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[0].Period) + " days, expected: 2 days)")
+	}
+
+	// Check for expected amount
+	if loanOffers[0].Amount != exchange.MustDecimal("100") {
+		t.Error("Returned wrong offer amount (" + loanOffers[0].Amount.String() + " , expected: 100)")
+	}
+
+	// Check that offers are not placed if there are insufficient funds
+	// Testing here because we are guaranteed to return at least one offer with the above settings
+	// Available balance 100, 101 required minimum
+	loanOffers = marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("101"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if none offers were returned
+	if len(loanOffers) != 0 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 0)")
+	}
+}
+
+func TestMarginBotGetLoanOffers_ThirtyDayDailyThreshold(t *testing.T) {
+	conf := MarginBotConf{
+		MinDailyLendRate:        0.1, // 36.5% / year
+		SpreadLend:              1,   // Generate only one offer
+		GapBottom:               0,   // Start from the lowest offer in the lendbook
+		ThirtyDayDailyThreshold: 1,   // if APR >= 365% / year => offer for 30 days
+	}
+
+	lendbook := bitfinex.Lendbook{
+		Asks: []bitfinex.LendbookOffer{
+			bitfinex.LendbookOffer{Rate: 1 * 365}, // APR == ThirtyDayDailyMin => make offer for 30 days
+			bitfinex.LendbookOffer{Rate: 2 * 365},
+			bitfinex.LendbookOffer{Rate: 3 * 365},
+		},
+	}
+
+	// Balance 100, no min for offers
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if only one offer was returned
+	if len(loanOffers) != 1 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 1)")
+	}
+
+	// Check for expected rate
+	if loanOffers[0].Rate != exchange.MustDecimal("365") {
+		t.Error("Returned wrong minimum offer rate (" + loanOffers[0].Rate.String() + " APR, expected: 365 APR)")
+	}
+
+	// Check for expected period
+	if loanOffers[0].Period != 30 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[0].Period) + " days, expected: 30 days)")
+	}
+
+	// Check for expected amount
+	if loanOffers[0].Amount != exchange.MustDecimal("100") {
+		t.Error("Returned wrong offer amount (" + loanOffers[0].Amount.String() + ", expected: 100)")
+	}
+}
+
+func TestMarginBotGetLoanOffers_HighHold(t *testing.T) {
+	conf := MarginBotConf{
+		HighHoldAmount:    10,
+		HighHoldDailyRate: 1, // 365 APR
+	}
+
+	lendbook := bitfinex.Lendbook{}
+
+	// Balance 100, no min for offers
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if only one offer was returned
+	if len(loanOffers) != 1 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 1)")
+	}
+
+	// Check for expected rate for HighHold
+	if loanOffers[0].Rate != exchange.MustDecimal("365") {
+		t.Error("Returned wrong minimum offer rate (" + loanOffers[0].Rate.String() + " APR, expected: 365 APR)")
+	}
+
+	// Check for expected period for HighHold
+	if loanOffers[0].Period != 30 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[0].Period) + " days, expected: 30 days)")
+	}
+
+	// Check for expected amount for HighHold
+	if loanOffers[0].Amount != exchange.MustDecimal("10") {
+		t.Error("Returned wrong offer amount (" + loanOffers[0].Amount.String() + " , expected: 10)")
+	}
+
+	// Balance only 5 (less than HighHold amount), no min for offers
+	loanOffers = marginBotGetLoanOffers(exchange.MustDecimal("5"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check for expected amount for HighHold
+	if loanOffers[0].Amount != exchange.MustDecimal("5") {
+		t.Error("Returned wrong offer amount (" + loanOffers[0].Amount.String() + " , expected: 10)")
+	}
+}
+
+func TestMarginBotGetLoanOffers_General(t *testing.T) {
+	// Fill lendbook with asks for: 0.1, 0.2...5.0 % daily
+	asks := []bitfinex.LendbookOffer{}
+	for i := 1; i <= 50; i++ {
+		asks = append(asks, bitfinex.LendbookOffer{Rate: (0.1 * float64(i)) * 365, Amount: 0.1})
+	}
+	lendbook := bitfinex.Lendbook{Asks: asks}
+
+	conf := MarginBotConf{
+		SpreadLend: 4,   // Split into 4 offers
+		GapBottom:  3.2, // Skip the lowest 31 offers in the landbook
+		GapTop:     4.3, // Make split accross 1 btc range
+
+		MinDailyLendRate:        3.3, // One potential offer (3.2 % / day) in the lendbook is below minimum (3.3 % / day)
+		ThirtyDayDailyThreshold: 4,   // One potential offer (4.1 % / day) in the lendbook is >= the 30 day threshold
+
+		HighHoldDailyRate: 365, // Make special HighHold offer at 365 * 365 APR
+		HighHoldAmount:    10,  // For 10 currency units
+	}
+
+	// Available balance 100, no minimum
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("110"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	// Check if 5 offers were returned (4 from split + 1 from HighHold)
+	if len(loanOffers) != 5 {
+		t.Error("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 5)")
+	}
+
+	// Populate expected offers
+	expectedOffers := MarginBotLoanOffers{
+		MarginBotLoanOffer{Amount: exchange.MustDecimal("10"), Rate: exchange.MustDecimal("133225"), Period: 30}, // Special HighHold offer
+		MarginBotLoanOffer{Amount: exchange.MustDecimal("25"), Rate: exchange.MustDecimal("1496.5"), Period: 30}, // Offer which has a rate abote the ThirtyDayDailyThreshold
+		MarginBotLoanOffer{Amount: exchange.MustDecimal("25"), Rate: exchange.MustDecimal("1204.5"), Period: 2},  // Offer which has a below minimum rate that was increased
+		MarginBotLoanOffer{Amount: exchange.MustDecimal("25"), Rate: exchange.MustDecimal("1277.5"), Period: 2},  // Normal offer
+		MarginBotLoanOffer{Amount: exchange.MustDecimal("25"), Rate: exchange.MustDecimal("1387"), Period: 2},    // Normal offer
+
+	}
+
+	// Check for expected offers (in any order)
+	for _, eo := range expectedOffers {
+		for i, lo := range loanOffers {
+			if eo.Period == lo.Period && eo.Rate == lo.Rate && eo.Amount == lo.Amount {
+				// Remove *only one* matching offer
+				loanOffers = append(loanOffers[:i], loanOffers[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(loanOffers) != 0 {
+		t.Errorf("Returned wrong loan offers (expected: %v)", expectedOffers)
+	}
+}
+
+func TestMarginBotGetLoanOffers_Precision(t *testing.T) {
+	conf := MarginBotConf{
+		MinDailyLendRate: 0.1,
+		SpreadLend:       3,
+		GapBottom:        0,
+		GapTop:           0,
+	}
+
+	lendbook := bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{bitfinex.LendbookOffer{Rate: 0.1 * 365, Amount: 1}}}
+
+	// A currency with 2 decimal places
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("1"), 2, lendbook, conf)
+
+	if len(loanOffers) != 3 {
+		t.Fatal("Returned wrong number of offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 3)")
+	}
+
+	for _, o := range loanOffers {
+		if o.Amount != exchange.MustDecimal("33.33") {
+			t.Error("Returned wrong offer amount (" + o.Amount.String() + ", expected: 33.33)")
+		}
+	}
+}
+
+func TestStrategyMarginBot_Reserve(t *testing.T) {
+	m := testMarket()
+	m.Reserve = 300
+
+	sconf := Config{MaxActiveAmount: -1}
+	sconf.Strategy.MarginBot = MarginBotConf{MinDailyLendRate: 0.01, SpreadLend: 2}
+
+	actions, err := strategyMarginBot(sconf, m)
+	if err != nil {
+		t.Fatal("Strategy failed: " + err.Error())
+	}
+
+	lent := exchange.Decimal{}
+	for _, a := range actions {
+		lent = lent.Add(a.Amount)
+	}
+
+	// 400 available + 600 offered - 300 reserve
+	if lent != exchange.MustDecimal("700") {
+		t.Error("Returned wrong lent amount (" + lent.String() + ", expected: 700)")
+	}
+}
+
+func TestMarginBotGetLoanOffers_OwnOffers(t *testing.T) {
+	conf := MarginBotConf{MinDailyLendRate: 0.01, SpreadLend: 3, GapBottom: 150, GapTop: 400}
+
+	market := []bitfinex.LendbookOffer{
+		{Rate: 0.10 * 365, Amount: 100},
+		{Rate: 0.15 * 365, Amount: 100},
+		{Rate: 0.20 * 365, Amount: 100},
+		{Rate: 0.25 * 365, Amount: 100},
+		{Rate: 0.30 * 365, Amount: 100},
+	}
+
+	// The bot's previous ladder is still in the book when the next run reads it
+	own := bitfinex.Offers{
+		{ID: 1, Rate: 0.12 * 365, RemainingAmount: 200},
+		{ID: 2, Rate: 0.18 * 365, RemainingAmount: 200},
+	}
+	withOwn := bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{
+		market[0], {Rate: 0.12 * 365, Amount: 200}, market[1], {Rate: 0.18 * 365, Amount: 200}, market[2], market[3], market[4],
+	}}
+
+	expected := marginBotGetLoanOffers(exchange.MustDecimal("400"), exchange.MustDecimal("50"), exchange.DecimalPlaces, bitfinex.Lendbook{Asks: market}, conf)
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("400"), exchange.MustDecimal("50"), exchange.DecimalPlaces, exchange.WithoutOffers(withOwn, own), conf)
+
+	if len(loanOffers) != len(expected) {
+		t.Fatal("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: " + strconv.Itoa(len(expected)) + ")")
+	}
+
+	for i := range expected {
+		if loanOffers[i].Rate != expected[i].Rate {
+			t.Error("Offer " + strconv.Itoa(i) + " priced against own offers (" + loanOffers[i].Rate.String() + " APR, expected: " + expected[i].Rate.String() + " APR)")
+		}
+	}
+
+	// Without removing them, the ladder moves relative to the previous one
+	moved := marginBotGetLoanOffers(exchange.MustDecimal("400"), exchange.MustDecimal("50"), exchange.DecimalPlaces, withOwn, conf)
+	if len(moved) == len(expected) && moved[0].Rate == expected[0].Rate && moved[len(moved)-1].Rate == expected[len(expected)-1].Rate {
+		t.Error("Own offers did not affect the ladder, test does not cover them")
+	}
+}
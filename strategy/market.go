@@ -0,0 +1,38 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// Market is the exchange data a strategy plans with, fetched once per plan
+type Market struct {
+	Currency string
+	Wallet   bitfinex.WalletBalance // Deposit wallet of the currency
+	Offers   bitfinex.Offers        // Active lend offers in the currency
+	Lendbook bitfinex.Lendbook
+	Info     exchange.CurrencyInfo
+	MinLoan  float64
+	Reserve  float64 // Kept unlent
+}
+
+// Offered returns the remaining amount of all active lend offers
+func (m Market) Offered() (amount float64) {
+	for _, o := range m.Offers {
+		amount += o.RemainingAmount
+	}
+
+	return
+}
+
+// dailyFRR returns the FRR of the lendbook, failing if there is none
+func (m Market) dailyFRR() (float64, error) {
+	frr := exchange.LendbookDailyFRR(m.Lendbook)
+	if frr <= 0 {
+		return 0, exchange.NewMarketDataError(exchange.MarketNoFRR, "No FRR in the "+m.Currency+" lendbook")
+	}
+
+	return frr, nil
+}
@@ -0,0 +1,54 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func testMarket() Market {
+	return Market{
+		Currency: "usd",
+		Wallet:   bitfinex.WalletBalance{Amount: 1000, Available: 400},
+		Offers: bitfinex.Offers{
+			bitfinex.Offer{ID: 1, Rate: 36.5, Period: 2, RemainingAmount: 300},
+			bitfinex.Offer{ID: 2, Rate: 40, Period: 2, RemainingAmount: 300},
+		},
+		Lendbook: bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 5000}}},
+		Info:     exchange.DefaultCurrencyInfo(),
+		MinLoan:  50,
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+func TestMarketDailyFRR(t *testing.T) {
+	m := Market{Currency: "usd", Info: exchange.DefaultCurrencyInfo(), Lendbook: bitfinex.Lendbook{Asks: []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 1}}}}
+
+	if _, err := m.dailyFRR(); exchange.ReasonOf(err) != exchange.MarketNoFRR {
+		t.Errorf("Missing FRR not reported: %v", err)
+	}
+
+	m.Lendbook.Asks = append(m.Lendbook.Asks, bitfinex.LendbookOffer{Rate: 73, Amount: 1, FRR: true})
+	if frr, err := m.dailyFRR(); err != nil || frr != 0.2 {
+		t.Errorf("Returned wrong FRR %v (%v), expected: 0.2", frr, err)
+	}
+}
+
+func TestMarginBotGetLoanOffers_EmptyLendbook(t *testing.T) {
+	conf := MarginBotConf{MinDailyLendRate: 0.1, SpreadLend: 3, GapTop: 100}
+
+	// Must not index into an empty book
+	if loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("1"), exchange.DecimalPlaces, bitfinex.Lendbook{}, conf); len(loanOffers) != 0 {
+		t.Error("Returned loan offers without a lendbook")
+	}
+}
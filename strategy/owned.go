@@ -0,0 +1,213 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"math"
+	"strings"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// OwnedOffers records which strategy created which offers and how much each strategy has lent out.
+// Strategies only ever cancel or re-price their own offers.
+type OwnedOffers struct {
+	Strategies map[string]*StrategyOffers // By lower case strategy name
+}
+
+// StrategyOffers ...
+type StrategyOffers struct {
+	Offers map[int]float64 // Remaining amount by offer ID, as last seen
+	Lent   float64         // Funds lent out through the strategy's offers
+}
+
+// owned returns the account's offer records, empty ones if there is no local state
+func (c Config) owned() *OwnedOffers {
+	if c.Owned == nil {
+		return &OwnedOffers{}
+	}
+
+	return c.Owned
+}
+
+// Copy returns a deep copy of the records, nil if there are none
+func (o *OwnedOffers) Copy() *OwnedOffers {
+	if o == nil {
+		return nil
+	}
+
+	c := &OwnedOffers{}
+	for name, so := range o.Strategies {
+		cso := c.strategy(name)
+		cso.Lent = so.Lent
+		for id, remaining := range so.Offers {
+			cso.Offers[id] = remaining
+		}
+	}
+
+	return c
+}
+
+// strategy returns the records of the strategy, creating them if needed
+func (o *OwnedOffers) strategy(name string) *StrategyOffers {
+	name = strings.ToLower(name)
+	if o.Strategies == nil {
+		o.Strategies = map[string]*StrategyOffers{}
+	}
+
+	so, ok := o.Strategies[name]
+	if !ok {
+		so = &StrategyOffers{Offers: map[int]float64{}}
+		o.Strategies[name] = so
+	}
+	if so.Offers == nil {
+		so.Offers = map[int]float64{}
+	}
+
+	return so
+}
+
+// Placed records a new offer of the strategy
+func (o *OwnedOffers) Placed(strategy string, id int, amount float64) {
+	if o != nil && strategy != "" {
+		o.strategy(strategy).Offers[id] = amount
+	}
+}
+
+// Cancelled forgets a cancelled offer, its remaining amount went back to the wallet
+func (o *OwnedOffers) Cancelled(id int) {
+	if o == nil {
+		return
+	}
+
+	for _, so := range o.Strategies {
+		delete(so.Offers, id)
+	}
+}
+
+// Adopt makes the strategy the owner of an offer created elsewhere
+func (o *OwnedOffers) Adopt(strategy string, offer bitfinex.Offer) {
+	o.Cancelled(offer.ID)
+	o.strategy(strategy).Offers[offer.ID] = offer.RemainingAmount
+}
+
+// offers returns the active offers the strategy created
+func (o *OwnedOffers) offers(m Market, strategy string) (offers bitfinex.Offers) {
+	so := o.strategy(strategy)
+	for _, offer := range m.Offers {
+		if _, ok := so.Offers[offer.ID]; ok {
+			offers = append(offers, offer)
+		}
+	}
+
+	return
+}
+
+// handOver gives the offers and lent funds of every other strategy to the strategy. Without
+// allocations the active strategy manages all of the bot's offers, including those placed
+// while a schedule rule had another strategy active.
+func (o *OwnedOffers) handOver(strategy string) {
+	so := o.strategy(strategy)
+	for name, other := range o.Strategies {
+		if other == so {
+			continue
+		}
+
+		for id, remaining := range other.Offers {
+			so.Offers[id] = remaining
+		}
+		so.Lent += other.Lent
+
+		delete(o.Strategies, name)
+	}
+}
+
+// reconcile updates the records from the active offers: funds of offers that were taken, fully
+// or partly, are counted as lent. Lent amounts are scaled down when loans return, so that
+// together they never exceed the funds actually lent out.
+func (o *OwnedOffers) reconcile(m Market) {
+	active := map[int]bitfinex.Offer{}
+	for _, offer := range m.Offers {
+		active[offer.ID] = offer
+	}
+
+	total := 0.0
+	for _, so := range o.Strategies {
+		for id, remaining := range so.Offers {
+			offer, ok := active[id]
+			if !ok {
+				so.Lent += remaining
+				delete(so.Offers, id)
+				continue
+			}
+
+			so.Lent += math.Max(0, remaining-offer.RemainingAmount)
+			so.Offers[id] = offer.RemainingAmount
+		}
+
+		total += so.Lent
+	}
+
+	// Neither available nor offered
+	lent := math.Max(0, m.Wallet.Amount-m.Wallet.Available-m.Offered())
+	if total > lent {
+		for _, so := range o.Strategies {
+			if lent > 0 {
+				so.Lent *= lent / total
+			} else {
+				so.Lent = 0
+			}
+		}
+	}
+}
+
+// subMarket returns the part of the market managed by the strategy: its own offers,
+// and the available funds within its share not already lent or offered. Spent funds
+// are taken out of idle.
+func (o *OwnedOffers) subMarket(m Market, strategy string, share float64, idle *float64) Market {
+	so := o.strategy(strategy)
+
+	sub := m
+	sub.Reserve = 0
+
+	sub.Offers = o.offers(m, strategy)
+
+	used := so.Lent
+	for _, offer := range sub.Offers {
+		used += offer.RemainingAmount
+	}
+
+	available := math.Min(math.Max(0, share-used), *idle)
+	*idle -= available
+
+	sub.Wallet = bitfinex.WalletBalance{Amount: share, Available: available}
+
+	return sub
+}
+
+// withoutOwnOffers removes the strategy's offers from the market's lendbook, they are about to be
+// re-priced and must not move their own ladder. Manual offers and those of other strategies stay.
+func withoutOwnOffers(m Market) (Market, error) {
+	m.Lendbook = exchange.WithoutOffers(m.Lendbook, m.Offers)
+
+	// Nothing may be left once the own offers are taken out
+	return m, exchange.ValidateLendbook(m.Lendbook)
+}
+
+// ownActions limits the strategy's actions to the offers it owns: cancelling all offers
+// becomes cancelling each of them
+func ownActions(actions PlanActions, offers bitfinex.Offers) (result PlanActions) {
+	for _, a := range actions {
+		if a.Action != ActionCancelAll {
+			result = append(result, a)
+			continue
+		}
+
+		for _, o := range offers {
+			result = append(result, PlanAction{Action: ActionCancel, OfferID: o.ID, Strategy: a.Strategy})
+		}
+	}
+
+	return
+}
@@ -0,0 +1,126 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestOwnedOffers_Reconcile(t *testing.T) {
+	owned := &OwnedOffers{}
+	owned.Placed("CascadeBot", 1, 300)
+	owned.Placed("MarginBot", 2, 300)
+	owned.Placed("MarginBot", 3, 100)
+	owned.Placed("", 4, 100) // Not owned
+
+	m := testMarket()
+	m.Wallet = bitfinex.WalletBalance{Amount: 1000, Available: 300}
+	m.Offers = bitfinex.Offers{
+		bitfinex.Offer{ID: 1, RemainingAmount: 200}, // Partly taken
+		bitfinex.Offer{ID: 3, RemainingAmount: 100},
+	}
+
+	owned.reconcile(m)
+
+	cb, mb := owned.strategy("cascadebot"), owned.strategy("marginbot")
+	if cb.Lent != 100 || mb.Lent != 300 {
+		t.Error("Returned wrong lent amounts (" + formatFloat(cb.Lent) + ", " + formatFloat(mb.Lent) + ", expected: 100, 300)")
+	}
+	if len(mb.Offers) != 1 || cb.Offers[1] != 200 {
+		t.Error("Offer records not updated")
+	}
+
+	// Half of the loans returned
+	m.Wallet.Available = 500
+	owned.reconcile(m)
+	if cb.Lent != 50 || mb.Lent != 150 {
+		t.Error("Returned wrong lent amounts (" + formatFloat(cb.Lent) + ", " + formatFloat(mb.Lent) + ", expected: 50, 150)")
+	}
+
+	// Cancelled offers are not counted as lent
+	owned.Cancelled(1)
+	m.Offers = m.Offers[1:]
+	m.Wallet.Available = 700
+	owned.reconcile(m)
+	if cb.Lent != 50 || len(cb.Offers) != 0 {
+		t.Error("Returned wrong lent amount (" + formatFloat(cb.Lent) + ", expected: 50)")
+	}
+}
+
+func TestOwnedOffers_Copy(t *testing.T) {
+	owned := &OwnedOffers{}
+	owned.Placed("MarginBot", 1, 300)
+	owned.strategy("marginbot").Lent = 100
+
+	c := owned.Copy()
+	c.reconcile(testMarket())
+	c.handOver("Harmonia")
+
+	if mb := owned.strategy("marginbot"); mb.Offers[1] != 300 || mb.Lent != 100 || len(owned.Strategies) != 1 {
+		t.Errorf("Changing the copy changed the records %+v", *mb)
+	}
+
+	if (*OwnedOffers)(nil).Copy() != nil {
+		t.Error("Copy of no records is not nil")
+	}
+}
+
+func TestOwnedOffers_Adopt(t *testing.T) {
+	owned := &OwnedOffers{}
+	owned.Placed("MarginBot", 1, 100)
+	owned.Adopt("cascadebot", bitfinex.Offer{ID: 1, RemainingAmount: 80})
+	owned.Adopt("CascadeBot", bitfinex.Offer{ID: 2, RemainingAmount: 50})
+
+	m := testMarket()
+	m.Offers = bitfinex.Offers{bitfinex.Offer{ID: 1}, bitfinex.Offer{ID: 2}, bitfinex.Offer{ID: 3}}
+
+	if offers := owned.offers(m, "MarginBot"); len(offers) != 0 {
+		t.Error("Adopted offer still owned by the previous strategy")
+	}
+	if offers := owned.offers(m, "CascadeBot"); len(offers) != 2 || owned.strategy("cascadebot").Offers[1] != 80 {
+		t.Error("Returned wrong number of owned offers (" + strconv.Itoa(len(offers)) + ", expected: 2)")
+	}
+}
+
+func TestWithoutOwnOffers(t *testing.T) {
+	m := testMarket()
+	m.Lendbook.Asks = []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 300}, {Rate: 40, Amount: 500}}
+
+	// Only offer 1 is the strategy's, the manual one at 40 %/year stays in the book
+	m.Offers = m.Offers[:1]
+	sub, err := withoutOwnOffers(m)
+	if err != nil || len(sub.Lendbook.Asks) != 1 || sub.Lendbook.Asks[0].Rate != 40 || sub.Lendbook.Asks[0].Amount != 500 {
+		t.Errorf("Returned wrong lendbook %+v: %v", sub.Lendbook.Asks, err)
+	}
+	if len(m.Lendbook.Asks) != 2 {
+		t.Error("Market lendbook changed")
+	}
+
+	// A book of only the strategy's own offers is empty once they are taken out
+	m.Lendbook.Asks = m.Lendbook.Asks[:1]
+	if _, err := withoutOwnOffers(m); exchange.ReasonOf(err) != exchange.MarketEmptyLendbook {
+		t.Errorf("Empty lendbook accepted: %v", err)
+	}
+}
+
+func TestOwnActions(t *testing.T) {
+	offers := bitfinex.Offers{bitfinex.Offer{ID: 1}, bitfinex.Offer{ID: 3}}
+	actions := ownActions(PlanActions{
+		PlanAction{Action: ActionCancelAll, Strategy: "MarginBot"},
+		PlanAction{Action: ActionLend, Amount: exchange.MustDecimal("100")},
+	}, offers)
+
+	if len(actions) != 3 || actions[0].Action != ActionCancel || actions[0].OfferID != 1 || actions[1].OfferID != 3 ||
+		actions[1].Strategy != "MarginBot" || actions[2].Action != ActionLend {
+		t.Errorf("Returned wrong actions %v", actions)
+	}
+
+	// Nothing to cancel without own offers
+	if actions := ownActions(PlanActions{PlanAction{Action: ActionCancelAll}}, nil); len(actions) != 0 {
+		t.Error("Returned wrong number of actions (" + strconv.Itoa(len(actions)) + ", expected: 0)")
+	}
+}
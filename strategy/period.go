@@ -0,0 +1,85 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+// PeriodCurvePoint ...
+type PeriodCurvePoint struct {
+	DailyRate float64
+	Period    int
+}
+
+// PeriodCurveConf ...
+type PeriodCurveConf struct {
+	RelativeToFRR bool
+	Points        []PeriodCurvePoint
+}
+
+// period maps the offered daily rate to a lending period by linearly interpolating
+// between the configured curve points. The strategy's own period is returned
+// if the curve is not configured.
+func (c PeriodCurveConf) period(dailyRate, dailyFRR float64, fallback int) int {
+	if len(c.Points) == 0 {
+		return fallback
+	}
+
+	x := dailyRate
+	if c.RelativeToFRR {
+		// Cannot place the rate relative to an unknown FRR
+		if dailyFRR <= 0 {
+			return fallback
+		}
+
+		x -= dailyFRR
+	}
+
+	points := make([]PeriodCurvePoint, len(c.Points))
+	copy(points, c.Points)
+	sort.Slice(points, func(i, j int) bool { return points[i].DailyRate < points[j].DailyRate })
+
+	period := float64(points[len(points)-1].Period)
+	if x <= points[0].DailyRate {
+		period = float64(points[0].Period)
+	} else {
+		for i := 1; i < len(points); i++ {
+			if x <= points[i].DailyRate {
+				lo, hi := points[i-1], points[i]
+				period = float64(lo.Period) + (x-lo.DailyRate)/(hi.DailyRate-lo.DailyRate)*float64(hi.Period-lo.Period)
+				break
+			}
+		}
+	}
+
+	return clampPeriod(int(math.Floor(period + 0.5)))
+}
+
+func clampPeriod(period int) int {
+	if period < exchange.MinLendPeriod {
+		return exchange.MinLendPeriod
+	}
+
+	if period > exchange.MaxLendPeriod {
+		return exchange.MaxLendPeriod
+	}
+
+	return period
+}
+
+// Validate checks the curve, problems are prefixed with its name
+func (c PeriodCurveConf) Validate(name string) (problems []string) {
+	for i, p := range c.Points {
+		if p.Period < exchange.MinLendPeriod || p.Period > exchange.MaxLendPeriod {
+			problems = append(problems, name+".Points["+strconv.Itoa(i)+"].Period must be in ["+
+				strconv.Itoa(exchange.MinLendPeriod)+", "+strconv.Itoa(exchange.MaxLendPeriod)+"] days")
+		}
+	}
+
+	return
+}
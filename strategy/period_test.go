@@ -0,0 +1,103 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+func TestPeriodCurve_Fallback(t *testing.T) {
+	conf := PeriodCurveConf{}
+
+	// No points => strategy's own period is used
+	if p := conf.period(1, 0, 7); p != 7 {
+		t.Error("Returned wrong period (" + strconv.Itoa(p) + " days, expected: 7 days)")
+	}
+
+	// Relative curve without known FRR => strategy's own period is used
+	conf = PeriodCurveConf{RelativeToFRR: true, Points: []PeriodCurvePoint{{DailyRate: 0, Period: 30}}}
+	if p := conf.period(1, 0, 7); p != 7 {
+		t.Error("Returned wrong period (" + strconv.Itoa(p) + " days, expected: 7 days)")
+	}
+}
+
+func TestPeriodCurve_Interpolation(t *testing.T) {
+	// Points given out of order on purpose
+	conf := PeriodCurveConf{
+		Points: []PeriodCurvePoint{
+			{DailyRate: 0.2, Period: 30},
+			{DailyRate: 0.1, Period: 2},
+		},
+	}
+
+	cases := []struct {
+		dailyRate float64
+		period    int
+	}{
+		{0.05, 2},  // Below the first point
+		{0.1, 2},   // On the first point
+		{0.15, 16}, // Halfway between the points
+		{0.2, 30},  // On the last point
+		{0.5, 30},  // Above the last point
+	}
+
+	for _, c := range cases {
+		if p := conf.period(c.dailyRate, 0, 0); p != c.period {
+			t.Error("Returned wrong period for " + strconv.FormatFloat(c.dailyRate, 'f', -1, 64) + " %/day (" +
+				strconv.Itoa(p) + " days, expected: " + strconv.Itoa(c.period) + " days)")
+		}
+	}
+}
+
+func TestPeriodCurve_RelativeToFRRAndClamp(t *testing.T) {
+	conf := PeriodCurveConf{
+		RelativeToFRR: true,
+		Points: []PeriodCurvePoint{
+			{DailyRate: 0, Period: 1},     // Below the exchange minimum
+			{DailyRate: 0.01, Period: 60}, // Above the exchange maximum
+		},
+	}
+
+	// At FRR => clamped to the minimum period
+	if p := conf.period(0.05, 0.05, 0); p != exchange.MinLendPeriod {
+		t.Error("Returned wrong period (" + strconv.Itoa(p) + " days, expected: " + strconv.Itoa(exchange.MinLendPeriod) + " days)")
+	}
+
+	// Well above FRR => clamped to the maximum period
+	if p := conf.period(0.07, 0.05, 0); p != exchange.MaxLendPeriod {
+		t.Error("Returned wrong period (" + strconv.Itoa(p) + " days, expected: " + strconv.Itoa(exchange.MaxLendPeriod) + " days)")
+	}
+}
+
+func TestMarginBotGetLoanOffers_PeriodCurve(t *testing.T) {
+	conf := MarginBotConf{
+		SpreadLend: 1,
+		PeriodCurve: PeriodCurveConf{
+			Points: []PeriodCurvePoint{
+				{DailyRate: 0, Period: 2},
+				{DailyRate: 1, Period: 12},
+			},
+		},
+	}
+
+	lendbook := bitfinex.Lendbook{
+		Asks: []bitfinex.LendbookOffer{
+			bitfinex.LendbookOffer{Rate: 0.5 * 365, Amount: 1},
+		},
+	}
+
+	loanOffers := marginBotGetLoanOffers(exchange.MustDecimal("100"), exchange.MustDecimal("0"), exchange.DecimalPlaces, lendbook, conf)
+
+	if len(loanOffers) != 1 {
+		t.Fatal("Returned wrong number of loan offers (" + strconv.Itoa(len(loanOffers)) + ", expected: 1)")
+	}
+
+	// 0.5 %/day is halfway along the curve
+	if loanOffers[0].Period != 7 {
+		t.Error("Returned wrong offer period (" + strconv.Itoa(loanOffers[0].Period) + " days, expected: 7 days)")
+	}
+}
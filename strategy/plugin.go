@@ -0,0 +1,341 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Plugin strategies are external executables: the market is written to their stdin
+// as JSON and the actions to take are read back from their stdout.
+
+package strategy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+const (
+	pluginProtocolVersion = 1
+	pluginDefaultTimeout  = 10 * time.Second
+	pluginWaitDelay       = time.Second // Waiting for output after the plugin is killed
+	pluginMaxOutput       = 1 << 20     // Bytes read from stdout
+	pluginMaxStderr       = 4096        // Bytes of stderr kept for error messages
+)
+
+// PluginConf ...
+type PluginConf struct {
+	Command        string          // Executable
+	Args           []string        // Arguments
+	Env            []string        // Extra "NAME=value" variables, nothing but PATH is inherited
+	TimeoutSeconds float64         // Defaults to 10
+	Params         json.RawMessage // Passed to the plugin as is
+
+	// The plugin must not be able to read the config file with the API keys: it either runs
+	// under a Sandbox wrapper, e.g. ["bwrap", "--ro-bind", "/usr", "/usr", ...], with the command
+	// and its arguments appended, or as another User (the bot must run as root), or both.
+	Sandbox     []string
+	User        string
+	Unsandboxed bool // Runs the plugin as the bot's user without a wrapper, it can read the API keys
+}
+
+// Check returns an error if the plugin would run with the bot's privileges without opting in
+func (c PluginConf) Check() error {
+	if len(c.Sandbox) == 0 && c.User == "" && !c.Unsandboxed {
+		return errors.New("Plugin requires a Sandbox wrapper or a User to run as, or Unsandboxed: true")
+	}
+	if len(c.Sandbox) > 0 && c.Sandbox[0] == "" {
+		return errors.New("Plugin.Sandbox command is empty")
+	}
+
+	return nil
+}
+
+// PluginRequest is written to the plugin's stdin
+type PluginRequest struct {
+	Version  int
+	Account  string
+	Currency string
+	Time     time.Time
+	Wallet   PluginWallet
+	Limits   PluginLimits
+	Offers   []PluginOffer         // The plugin's own active offers
+	Lendbook []exchange.DepthLevel // Asks
+	DailyFRR float64               `json:",omitempty"`
+	Params   json.RawMessage       `json:",omitempty"`
+}
+
+// PluginWallet ...
+type PluginWallet struct {
+	Amount    float64
+	Available float64
+	Reserve   float64 // Must stay unlent
+	Lendable  float64 // Most that may be offered after cancelling all of the plugin's offers
+}
+
+// PluginLimits ...
+type PluginLimits struct {
+	MinLoan      float64
+	AmountPlaces int
+	MinPeriod    int
+	MaxPeriod    int
+	MaxDailyRate float64
+}
+
+// PluginOffer ...
+type PluginOffer struct {
+	ID        int
+	Amount    float64 // Remaining
+	DailyRate float64
+	Period    int
+	Created   time.Time
+}
+
+// PluginResponse is read from the plugin's stdout
+type PluginResponse struct {
+	Actions []PluginAction
+}
+
+// PluginAction ...
+type PluginAction struct {
+	Action    string // "cancel", "cancel_all" or "lend"
+	OfferID   int    `json:",omitempty"`
+	Amount    exchange.Decimal
+	DailyRate float64
+	Period    int
+	Note      string `json:",omitempty"` // Shown with --explain
+}
+
+// limitedBuffer keeps up to limit bytes and drops the rest, so that the plugin is never
+// blocked on a full pipe
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.exceeded = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
+
+func strategyPlugin(sconf Config, m Market) (planActions PlanActions, err error) {
+	conf := sconf.Strategy.Plugin
+	req := pluginRequest(sconf, m)
+
+	sconf.logger().Println("\tRunning plugin " + conf.Command + "...")
+	resp, err := conf.run(req)
+	if err != nil {
+		return
+	}
+
+	return pluginActions(resp, req, sconf.Explain)
+}
+
+// pluginRequest describes the market to the plugin
+func pluginRequest(sconf Config, m Market) PluginRequest {
+	offered := m.Offered()
+
+	// Same funds MarginBot would lend after cancelling its offers
+	lendable := m.Wallet.Available + offered
+	if sconf.MaxActiveAmount >= 0 {
+		lendable = math.Min(lendable, sconf.MaxActiveAmount-(m.Wallet.Amount-m.Wallet.Available-offered))
+	}
+	lendable = math.Max(0, lendable-m.Reserve)
+
+	req := PluginRequest{
+		Version:  pluginProtocolVersion,
+		Account:  sconf.Account,
+		Currency: m.Currency,
+		Time:     time.Now().UTC(),
+		Wallet:   PluginWallet{Amount: m.Wallet.Amount, Available: m.Wallet.Available, Reserve: m.Reserve, Lendable: lendable},
+		Limits: PluginLimits{MinLoan: m.MinLoan, AmountPlaces: m.Info.AmountPlaces, MinPeriod: m.Info.MinPeriod,
+			MaxPeriod: m.Info.MaxPeriod, MaxDailyRate: m.Info.MaxDailyRate},
+		DailyFRR: exchange.LendbookDailyFRR(m.Lendbook),
+		Params:   sconf.Strategy.Plugin.Params,
+	}
+
+	for _, o := range m.Offers {
+		req.Offers = append(req.Offers, PluginOffer{ID: o.ID, Amount: o.RemainingAmount, DailyRate: o.Rate / 365, Period: o.Period,
+			Created: time.Unix(int64(o.Timestamp), 0).UTC()})
+	}
+
+	for _, o := range m.Lendbook.Asks {
+		req.Lendbook = append(req.Lendbook, exchange.DepthLevel{Rate: o.Rate, Amount: o.Amount, FRR: o.FRR})
+	}
+
+	return req
+}
+
+// run executes the plugin with the request on its stdin, under the Sandbox wrapper and as the
+// User if set. The plugin gets an empty temporary working directory and no environment besides
+// PATH and the configured variables, and is killed when it runs longer than the timeout.
+func (c PluginConf) run(req PluginRequest) (resp PluginResponse, err error) {
+	err = c.Check()
+	if err != nil {
+		return
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+
+	timeout := pluginDefaultTimeout
+	if c.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.TimeoutSeconds * float64(time.Second))
+	}
+
+	dir, err := ioutil.TempDir("", "blb-plugin")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: pluginMaxOutput}
+	stderr := &limitedBuffer{limit: pluginMaxStderr}
+
+	argv := append(append(append([]string{}, c.Sandbox...), c.Command), c.Args...)
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}, c.Env...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	killGroupOnCancel(cmd)
+
+	if c.User != "" {
+		err = runAs(cmd, c.User, dir)
+		if err != nil {
+			return resp, errors.New("Failed to run plugin as " + c.User + ": " + err.Error())
+		}
+	}
+
+	// Children still holding stdout open must not block the run after the plugin is killed
+	cmd.WaitDelay = pluginWaitDelay
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return resp, errors.New("Plugin timed out after " + timeout.String())
+	}
+	if err != nil {
+		msg := "Plugin failed: " + err.Error()
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			msg += ": " + s
+		}
+
+		return resp, errors.New(msg)
+	}
+	if stdout.exceeded {
+		return resp, errors.New("Plugin output exceeds " + strconv.Itoa(pluginMaxOutput) + " bytes")
+	}
+
+	decoder := json.NewDecoder(&stdout.Buffer)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&resp)
+	if err != nil {
+		return resp, errors.New("Invalid plugin response: " + err.Error())
+	}
+
+	return
+}
+
+// pluginActions checks the plugin's actions against the request and turns them into plan actions.
+// Any invalid action rejects the whole response.
+func pluginActions(resp PluginResponse, req PluginRequest, explain bool) (planActions PlanActions, err error) {
+	offers := map[int]bool{}
+	for _, o := range req.Offers {
+		offers[o.ID] = true
+	}
+
+	places := req.Limits.AmountPlaces
+	minLoan := exchange.MinLoanDecimal(req.Limits.MinLoan, places)
+
+	// Lends may use whatever the offers left active do not
+	cancelled := map[int]bool{}
+	lent := exchange.Decimal{}
+
+	for i, a := range resp.Actions {
+		invalid := func(msg string) error {
+			return errors.New("Invalid plugin action #" + strconv.Itoa(i) + " (" + a.Action + "): " + msg)
+		}
+
+		var pa PlanAction
+		switch a.Action {
+		case ActionCancelAll:
+			for id := range offers {
+				cancelled[id] = true
+			}
+
+			pa = PlanAction{Action: ActionCancelAll}
+		case ActionCancel:
+			if !offers[a.OfferID] {
+				return nil, invalid("offer " + strconv.Itoa(a.OfferID) + " is not one of the plugin's offers")
+			}
+			if cancelled[a.OfferID] {
+				return nil, invalid("offer " + strconv.Itoa(a.OfferID) + " is already cancelled")
+			}
+
+			cancelled[a.OfferID] = true
+
+			pa = PlanAction{Action: ActionCancel, OfferID: a.OfferID}
+		case ActionLend:
+			amount := a.Amount.Round(places, exchange.RoundDown)
+			if amount.Cmp(minLoan) < 0 {
+				return nil, invalid("amount " + a.Amount.String() + " is below the minimum loan " + minLoan.String())
+			}
+			if !(a.DailyRate > 0) || a.DailyRate > req.Limits.MaxDailyRate {
+				return nil, invalid("rate " + rateStr(a.DailyRate) + " is not in (0, " + rateStr(req.Limits.MaxDailyRate) + "]")
+			}
+			if a.Period < req.Limits.MinPeriod || a.Period > req.Limits.MaxPeriod {
+				return nil, invalid("period " + strconv.Itoa(a.Period) + " is not in [" + strconv.Itoa(req.Limits.MinPeriod) + ", " +
+					strconv.Itoa(req.Limits.MaxPeriod) + "] days")
+			}
+
+			lent = lent.Add(amount)
+			pa = PlanAction{Action: ActionLend, Amount: amount, YearlyRate: exchange.RateDecimal(a.DailyRate * 365), Period: a.Period}
+		default:
+			return nil, invalid("unknown action")
+		}
+
+		if explain && a.Note != "" {
+			pa.Explain.add("plugin", a.Note, nil)
+		}
+
+		planActions = append(planActions, pa)
+	}
+
+	funds := math.Max(0, req.Wallet.Lendable-offeredAfter(req.Offers, cancelled))
+	if lent.Cmp(exchange.DecimalFromFloat(funds).Round(places, exchange.RoundDown)) > 0 {
+		return nil, errors.New("Plugin lends " + lent.String() + " " + req.Currency + ", more than the " + formatFloat(funds) + " available")
+	}
+
+	return
+}
+
+// offeredAfter returns the remaining amount of the offers left active
+func offeredAfter(offers []PluginOffer, cancelled map[int]bool) (amount float64) {
+	for _, o := range offers {
+		if !cancelled[o.ID] {
+			amount += o.Amount
+		}
+	}
+
+	return
+}
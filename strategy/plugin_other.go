@@ -0,0 +1,18 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+//go:build !unix
+
+package strategy
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// killGroupOnCancel only kills the plugin itself, WaitDelay stops waiting for its children
+func killGroupOnCancel(cmd *exec.Cmd) {}
+
+// runAs is not supported, only a Sandbox wrapper can isolate the plugin
+func runAs(cmd *exec.Cmd, name, dir string) error {
+	return errors.New("Plugin.User is only supported on Unix")
+}
@@ -0,0 +1,196 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+	"github.com/eAndrius/bitfinex-go"
+)
+
+// TestPluginHelper is not a test, it is run as the plugin by the tests below
+func TestPluginHelper(t *testing.T) {
+	mode := os.Getenv("BLB_TEST_PLUGIN")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	var req PluginRequest
+	json.NewDecoder(os.Stdin).Decode(&req)
+
+	switch mode {
+	case "lend":
+		// Re-lend everything at FRR plus the configured increment
+		var params struct{ Inc float64 }
+		json.Unmarshal(req.Params, &params)
+
+		fmt.Printf(`{"Actions": [{"Action": "cancel_all"}, {"Action": "lend", "Amount": %v, "DailyRate": %v, "Period": 2, "Note": "FRR + Inc"}]}`,
+			req.Wallet.Lendable, req.DailyFRR+params.Inc)
+	case "env":
+		// Reports a secret leaking into the environment
+		if os.Getenv("BLB_SECRET") != "" {
+			fmt.Print(`{"Actions": [{"Action": "leak"}]}`)
+		} else {
+			fmt.Print(`{"Actions": []}`)
+		}
+	case "sandboxed":
+		// Reports running without the wrapper
+		if os.Getenv("BLB_SANDBOXED") == "" {
+			fmt.Print(`{"Actions": [{"Action": "leak"}]}`)
+		} else {
+			fmt.Print(`{"Actions": []}`)
+		}
+	case "sleep":
+		time.Sleep(5 * time.Second)
+	case "fork":
+		// Like sh -c or a sandbox wrapper: a child that keeps stdout open
+		child := exec.Command(os.Args[0], "-test.run=TestPluginHelper")
+		child.Env = append(os.Environ(), "BLB_TEST_PLUGIN=sleep")
+		child.Stdout = os.Stdout
+		child.Start()
+		child.Wait()
+	case "fail":
+		fmt.Fprint(os.Stderr, "bad params")
+		os.Exit(3)
+	case "garbage":
+		fmt.Print(`{"Actions": [], "Extra": 1}`)
+	}
+}
+
+func testPluginConf(mode string) PluginConf {
+	return PluginConf{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestPluginHelper"},
+		Env:     []string{"BLB_TEST_PLUGIN=" + mode},
+		Params:  json.RawMessage(`{"Inc": 0.01}`),
+
+		Unsandboxed: true,
+	}
+}
+
+func TestStrategyPlugin(t *testing.T) {
+	m := testMarket()
+	m.Lendbook.Asks[0].FRR = true
+
+	conf := Config{MaxActiveAmount: -1, Explain: true}
+	conf.Strategy.Plugin = testPluginConf("lend")
+
+	actions, err := strategyPlugin(conf, m)
+	if err != nil {
+		t.Fatal("Plugin failed: " + err.Error())
+	}
+
+	if len(actions) != 2 || actions[0].Action != ActionCancelAll || actions[1].Action != ActionLend {
+		t.Fatalf("Returned wrong actions %v", actions)
+	}
+	if actions[1].Amount != exchange.MustDecimal("1000") || math.Abs(actions[1].YearlyRate.Float64()-(0.1+0.01)*365) > 0.00001 || actions[1].Period != 2 {
+		t.Errorf("Returned wrong lend %v", actions[1])
+	}
+	if len(actions[1].Explain) != 1 || actions[1].Explain[0].Note != "FRR + Inc" {
+		t.Error("Plugin note not kept")
+	}
+}
+
+func TestPluginConf_Run(t *testing.T) {
+	os.Setenv("BLB_SECRET", "secret")
+	defer os.Unsetenv("BLB_SECRET")
+
+	// The environment is not inherited
+	if resp, err := testPluginConf("env").run(PluginRequest{}); err != nil || len(resp.Actions) != 0 {
+		t.Error("Plugin saw the environment: " + errString(err))
+	}
+
+	tests := []struct {
+		mode, err string
+	}{
+		{"fail", "bad params"},
+		{"garbage", "Invalid plugin response"},
+		{"sleep", "timed out"},
+		{"fork", "timed out"},
+	}
+
+	for _, tt := range tests {
+		conf := testPluginConf(tt.mode)
+		conf.TimeoutSeconds = 0.5
+
+		start := time.Now()
+		_, err := conf.run(PluginRequest{})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Error("Returned wrong error for " + tt.mode + " (" + errString(err) + ", expected: " + tt.err + ")")
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Error("Plugin " + tt.mode + " ran for " + elapsed.String() + " despite the timeout")
+		}
+	}
+}
+
+func TestPluginConf_Sandbox(t *testing.T) {
+	conf := testPluginConf("sandboxed")
+	conf.Unsandboxed = false
+
+	if _, err := conf.run(PluginRequest{}); err == nil || !strings.Contains(err.Error(), "Sandbox") {
+		t.Error("Plugin ran without a sandbox (" + errString(err) + ")")
+	}
+
+	// The wrapper runs the plugin
+	conf.Sandbox = []string{"env", "BLB_SANDBOXED=1"}
+	if resp, err := conf.run(PluginRequest{}); err != nil || len(resp.Actions) != 0 {
+		t.Error("Plugin did not run under the wrapper: " + errString(err))
+	}
+
+	conf.Sandbox = nil
+	conf.User = "blb-no-such-user"
+	if _, err := conf.run(PluginRequest{}); err == nil || !strings.Contains(err.Error(), "Failed to run plugin as") {
+		t.Error("Plugin ran as an unknown user (" + errString(err) + ")")
+	}
+}
+
+func TestPluginActions(t *testing.T) {
+	req := pluginRequest(Config{MaxActiveAmount: -1}, testMarket())
+	lend := func(amount string, dailyRate float64, period int) PluginAction {
+		return PluginAction{Action: ActionLend, Amount: exchange.MustDecimal(amount), DailyRate: dailyRate, Period: period}
+	}
+
+	tests := []struct {
+		actions []PluginAction
+		err     string
+	}{
+		{[]PluginAction{lend("400", 0.1, 2)}, ""},
+		{[]PluginAction{{Action: ActionCancel, OfferID: 1}, lend("700", 0.1, 30)}, ""},
+		{[]PluginAction{{Action: ActionCancelAll}, lend("500", 0.1, 2), lend("500", 0.2, 2)}, ""},
+		{[]PluginAction{lend("401", 0.1, 2)}, "more than"},
+		{[]PluginAction{{Action: ActionCancel, OfferID: 1}, lend("701", 0.1, 2)}, "more than"},
+		{[]PluginAction{{Action: ActionCancel, OfferID: 9}}, "not one of"},
+		{[]PluginAction{{Action: ActionCancel, OfferID: 1}, {Action: ActionCancel, OfferID: 1}}, "already cancelled"},
+		{[]PluginAction{lend("10", 0.1, 2)}, "minimum loan"},
+		{[]PluginAction{lend("100", 0, 2)}, "rate"},
+		{[]PluginAction{lend("100", 8, 2)}, "rate"},
+		{[]PluginAction{lend("100", 0.1, 31)}, "period"},
+		{[]PluginAction{{Action: "borrow"}}, "unknown action"},
+	}
+
+	for i, tt := range tests {
+		_, err := pluginActions(PluginResponse{Actions: tt.actions}, req, false)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Case %d: returned wrong error (%s, expected: %s)", i, errString(err), tt.err)
+		}
+	}
+
+	// Lendable funds respect the reserve and the active amount limit
+	m := testMarket()
+	m.Reserve = 100
+	m.Offers = bitfinex.Offers{m.Offers[0]}
+	req = pluginRequest(Config{MaxActiveAmount: 500}, m)
+	if req.Wallet.Lendable != 100 {
+		t.Error("Returned wrong lendable amount (" + formatFloat(req.Wallet.Lendable) + ", expected: 100)")
+	}
+}
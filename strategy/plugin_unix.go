@@ -0,0 +1,49 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+//go:build unix
+
+package strategy
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// killGroupOnCancel runs the plugin in its own process group and kills the whole group when
+// the context is done, so that children of a wrapper like sh -c or bwrap do not outlive it
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// runAs drops the plugin to the user's uid and gid, without supplementary groups,
+// and hands it the working directory
+func runAs(cmd *exec.Cmd, name, dir string) error {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chown(dir, int(uid), int(gid))
+	if err != nil {
+		return err
+	}
+
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+// Script strategy: the offer ladder is described by expressions (see expr.go)
+// instead of fixed parameters, e.g. "max(FRR + 0.002, lends(75, 50))".
+
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+// Lends fetched from the exchange for the lends() function
+const scriptLendsLimit = 200
+
+// Variables available to every Script expression
+var scriptBaseVars = []string{"FRR", "WalletAmount", "WalletAvailable", "Offered", "MinLoan", "Reserve"}
+
+// ScriptConf ...
+type ScriptConf struct {
+	Keep   Expr // Evaluated for each active offer, non-zero keeps it instead of re-lending its funds
+	Splits Expr // Number of offers to place, defaults to 1
+	Amount Expr // Relative amount of offer I, defaults to equal amounts
+	Rate   Expr // Daily rate of offer I
+	Period Expr // Period of offer I in days, defaults to 2
+}
+
+// fields returns the expressions with their names and the variables each may use
+func (c ScriptConf) fields() []struct {
+	name string
+	expr Expr
+	vars []string
+} {
+	vars := func(extra ...string) []string {
+		return append(append([]string{}, scriptBaseVars...), extra...)
+	}
+
+	return []struct {
+		name string
+		expr Expr
+		vars []string
+	}{
+		{"Keep", c.Keep, vars("Age", "Rate", "Amount", "Period")},
+		{"Splits", c.Splits, vars("Available")},
+		{"Amount", c.Amount, vars("Available", "N", "I")},
+		{"Rate", c.Rate, vars("Available", "N", "I")},
+		{"Period", c.Period, vars("Available", "N", "I", "Rate")},
+	}
+}
+
+// Check makes sure every expression only uses the variables available to it
+func (c ScriptConf) Check() error {
+	for _, f := range c.fields() {
+		if unknown := f.expr.unknownVars(f.vars); len(unknown) > 0 {
+			return errors.New("Script." + f.name + ": unknown variable(s) " + strings.Join(unknown, ", ") +
+				" in \"" + f.expr.String() + "\" (available: " + strings.Join(f.vars, ", ") + ")")
+		}
+	}
+
+	return nil
+}
+
+// uses returns true if any expression calls the function
+func (c ScriptConf) uses(fn string) bool {
+	for _, f := range c.fields() {
+		if f.expr.uses(fn) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnmarshalJSON compiles and checks the expressions when the config is loaded
+func (c *ScriptConf) UnmarshalJSON(data []byte) error {
+	type plain ScriptConf
+	err := json.Unmarshal(data, (*plain)(c))
+	if err != nil {
+		return err
+	}
+
+	return c.Check()
+}
+
+// withVars returns the base variables and the extra ones
+func withVars(base, extra map[string]float64) map[string]float64 {
+	vars := map[string]float64{}
+	for k, v := range base {
+		vars[k] = v
+	}
+	for k, v := range extra {
+		vars[k] = v
+	}
+
+	return vars
+}
+
+func strategyScript(sconf Config, m Market) (planActions PlanActions, err error) {
+	conf := sconf.Strategy.Script
+	if conf.Rate.Empty() {
+		return nil, errors.New("Script.Rate is required")
+	}
+
+	FRR, err := m.dailyFRR()
+	if err != nil {
+		return
+	}
+
+	env := &exprEnv{asks: m.Lendbook.Asks}
+	if conf.uses("lends") {
+		sconf.logger().Println("\tGetting recent lends...")
+
+		lends, err := sconf.ExtAPI.Lends(m.Currency, scriptLendsLimit)
+		if err != nil {
+			return nil, errors.New("Failed to get recent lends: " + err.Error())
+		}
+
+		for _, l := range lends {
+			env.lends = append(env.lends, l.Rate/365)
+		}
+	}
+
+	base := map[string]float64{"FRR": FRR, "WalletAmount": m.Wallet.Amount, "WalletAvailable": m.Wallet.Available,
+		"Offered": m.Offered(), "MinLoan": m.MinLoan, "Reserve": m.Reserve}
+
+	// Offers not kept are cancelled and their funds lent again
+	available, kept := m.Wallet.Available, 0.0
+	for _, o := range m.Offers {
+		keep := 0.0
+		if !conf.Keep.Empty() {
+			env.vars = withVars(base, map[string]float64{"Age": float64(time.Now().Unix()-int64(o.Timestamp)) / 60,
+				"Rate": o.Rate / 365, "Amount": o.RemainingAmount, "Period": float64(o.Period)})
+
+			keep, err = conf.Keep.eval(env)
+			if err != nil {
+				return nil, errors.New("Script.Keep: " + err.Error())
+			}
+		}
+
+		if keep != 0 {
+			kept += o.RemainingAmount
+			continue
+		}
+
+		a := PlanAction{Action: ActionCancel, OfferID: o.ID}
+		if sconf.Explain && !conf.Keep.Empty() {
+			a.Explain.add("keep", "Keep \""+conf.Keep.String()+"\" is false for offer "+strconv.Itoa(o.ID), nil)
+		}
+
+		planActions = append(planActions, a)
+		available += o.RemainingAmount
+	}
+
+	// Check if we need to limit our usage
+	if sconf.MaxActiveAmount >= 0 {
+		lent := m.Wallet.Amount - m.Wallet.Available - m.Offered()
+		available = math.Min(available, sconf.MaxActiveAmount-lent-kept)
+	}
+
+	// Keep the reserve unlent
+	available = math.Max(0, available-m.Reserve)
+
+	places := m.Info.AmountPlaces
+	funds, minLoan := exchange.AmountDecimal(available, places), exchange.MinLoanDecimal(m.MinLoan, places)
+	if funds.Cmp(minLoan) < 0 {
+		return
+	}
+
+	base["Available"] = funds.Float64()
+	env.vars = base
+
+	splits := 1
+	if !conf.Splits.Empty() {
+		n, err := conf.Splits.eval(env)
+		if err != nil {
+			return nil, errors.New("Script.Splits: " + err.Error())
+		}
+
+		splits = int(math.Floor(n))
+	}
+	if splits <= 0 {
+		return
+	}
+
+	base["N"] = float64(splits)
+
+	// Relative amounts
+	weights, total := make([]float64, splits), 0.0
+	for i := range weights {
+		weights[i] = 1
+		if !conf.Amount.Empty() {
+			env.vars = withVars(base, map[string]float64{"I": float64(i)})
+
+			weights[i], err = conf.Amount.eval(env)
+			if err != nil {
+				return nil, errors.New("Script.Amount: " + err.Error())
+			}
+			if weights[i] < 0 {
+				return nil, errors.New("Script.Amount: offer " + strconv.Itoa(i) + " amount is negative")
+			}
+		}
+
+		total += weights[i]
+	}
+	if total <= 0 {
+		return
+	}
+
+	left := funds
+	for i, w := range weights {
+		// Shares are rounded, never lend more than the funds
+		amount := exchange.MinDecimal(exchange.AmountDecimal(funds.Float64()*w/total, places), left)
+		if amount.Cmp(minLoan) < 0 {
+			continue
+		}
+		left = left.Sub(amount)
+
+		env.vars = withVars(base, map[string]float64{"I": float64(i)})
+
+		rate, err := conf.Rate.eval(env)
+		if err != nil {
+			return nil, errors.New("Script.Rate: " + err.Error())
+		}
+		if rate <= 0 {
+			return nil, errors.New("Script.Rate: offer " + strconv.Itoa(i) + " rate " + rateStr(rate) + " is not positive")
+		}
+
+		period := m.Info.ClampPeriod(2)
+		if !conf.Period.Empty() {
+			env.vars["Rate"] = rate
+
+			days, err := conf.Period.eval(env)
+			if err != nil {
+				return nil, errors.New("Script.Period: " + err.Error())
+			}
+
+			// Compared before the conversion, out of range values do not fit an int
+			days = math.Floor(days + 0.5)
+			if days < float64(m.Info.MinPeriod) || days > float64(m.Info.MaxPeriod) {
+				return nil, errors.New("Script.Period: offer " + strconv.Itoa(i) + " period \"" + conf.Period.String() + "\" = " + formatFloat(days) +
+					" days is outside [" + strconv.Itoa(m.Info.MinPeriod) + ", " + strconv.Itoa(m.Info.MaxPeriod) + "] days for " + m.Currency)
+			}
+			period = int(days)
+		}
+
+		a := PlanAction{Action: ActionLend, Amount: amount, YearlyRate: exchange.RateDecimal(rate * 365), Period: period}
+		if sconf.Explain {
+			a.Explain.add("amount", "share "+amountStr(w)+"/"+amountStr(total)+" of available "+funds.String()+" = "+amount.String(),
+				map[string]float64{"I": float64(i), "Available": funds.Float64(), "Amount": amount.Float64()})
+			a.Explain.add("rate", "Rate \""+conf.Rate.String()+"\" = "+rateStr(rate), map[string]float64{"DailyRate": rate, "DailyFRR": FRR})
+			if !conf.Period.Empty() {
+				a.Explain.add("period", "Period \""+conf.Period.String()+"\" = "+strconv.Itoa(a.Period)+" days", nil)
+			}
+		}
+
+		planActions = append(planActions, a)
+	}
+
+	return
+}
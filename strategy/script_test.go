@@ -0,0 +1,147 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+package strategy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+func TestScriptConf_Load(t *testing.T) {
+	var conf Conf
+	err := json.Unmarshal([]byte(`{"Active": "Script", "Script": {"Keep": "Age < 60", "Rate": "FRR + Age"}}`), &conf)
+	if err == nil || !strings.Contains(err.Error(), "Script.Rate: unknown variable(s) Age") {
+		t.Error("Returned wrong error (" + errString(err) + ", expected: unknown variable)")
+	}
+
+	err = json.Unmarshal([]byte(`{"Script": {"Rate": "max(FRR"}}`), &conf)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Error("Returned wrong error (" + errString(err) + ", expected: missing \")\")")
+	}
+
+	conf = Conf{}
+	err = json.Unmarshal([]byte(`{"Active": "Script", "Script": {"Splits": "3", "Rate": "FRR + I * 0.01"}}`), &conf)
+	if err != nil {
+		t.Fatal("Failed to load: " + err.Error())
+	}
+
+	// Schedules replace single expressions
+	sconf := conf
+	err = json.Unmarshal([]byte(`{"Script": {"Splits": "1"}}`), &sconf)
+	if err != nil || sconf.Script.Splits.String() != "1" || sconf.Script.Rate.String() != "FRR + I * 0.01" {
+		t.Error("Partial Script settings not applied")
+	}
+}
+
+func TestStrategyScript(t *testing.T) {
+	m := testMarket()
+	m.Lendbook.Asks[0].FRR = true
+
+	conf := Config{MaxActiveAmount: -1}
+	err := json.Unmarshal([]byte(`{
+		"Keep": "Rate > 0.105 && Age > 0",
+		"Splits": "min(3, floor(Available / 200))",
+		"Amount": "I + 1",
+		"Rate": "FRR + I * 0.01",
+		"Period": "if(Rate >= 0.12, 30, 2)"
+	}`), &conf.Strategy.Script)
+	if err != nil {
+		t.Fatal("Failed to load: " + err.Error())
+	}
+
+	actions, err := strategyScript(conf, m)
+	if err != nil {
+		t.Fatal("Strategy failed: " + err.Error())
+	}
+
+	// Offer 1 (0.1 %/day) is re-lent, offer 2 (0.1096 %/day) kept
+	expected := PlanActions{
+		PlanAction{Action: ActionCancel, OfferID: 1},
+		PlanAction{Action: ActionLend, Amount: exchange.MustDecimal("116.66666667"), YearlyRate: exchange.RateDecimal(36.5), Period: 2},
+		PlanAction{Action: ActionLend, Amount: exchange.MustDecimal("233.33333333"), YearlyRate: exchange.RateDecimal(0.11 * 365), Period: 2},
+		PlanAction{Action: ActionLend, Amount: exchange.MustDecimal("350"), YearlyRate: exchange.RateDecimal(0.12 * 365), Period: 30},
+	}
+
+	if len(actions) != len(expected) {
+		t.Fatal("Returned wrong number of actions (" + strconv.Itoa(len(actions)) + ", expected: " + strconv.Itoa(len(expected)) + ")")
+	}
+
+	for i := range actions {
+		a, e := actions[i], expected[i]
+		if a.Action != e.Action || a.OfferID != e.OfferID || a.Amount != e.Amount || a.YearlyRate != e.YearlyRate || a.Period != e.Period {
+			t.Errorf("Action %d: returned %v, expected: %v", i, a, e)
+		}
+	}
+}
+
+func TestStrategyScript_Period(t *testing.T) {
+	m := testMarket()
+	m.Lendbook.Asks[0].FRR = true
+	m.Offers = nil
+
+	tests := []struct {
+		period string
+		days   int
+		err    string
+	}{
+		{"", 2, ""},
+		{"Rate * 100", 10, ""},
+		{"1", 0, "period \"1\" = 1 days is outside [2, 30] days"},
+		{"Rate * 1000", 0, "period \"Rate * 1000\" = 100 days is outside"},
+		{"WalletAmount * 10000000000000000000000", 0, "period \"WalletAmount * 10000000000000000000000\""},
+		{"0 / 0", 0, "is not a number"},
+	}
+
+	for _, tt := range tests {
+		conf := Config{MaxActiveAmount: -1}
+		err := json.Unmarshal([]byte(`{"Rate": "FRR", "Period": "`+tt.period+`"}`), &conf.Strategy.Script)
+		if err != nil {
+			t.Fatal("Failed to load \"" + tt.period + "\": " + err.Error())
+		}
+
+		actions, err := strategyScript(conf, m)
+		if tt.err == "" && (err != nil || len(actions) != 1 || actions[0].Period != tt.days) {
+			t.Errorf("Period \"%s\": returned wrong actions %v: %v", tt.period, actions, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), "Script.Period") || !strings.Contains(err.Error(), tt.err)) {
+			t.Error("Returned wrong error for \"" + tt.period + "\" (" + errString(err) + ", expected: " + tt.err + ")")
+		}
+	}
+}
+
+func TestStrategyScript_Lends(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/lends/usd" || r.URL.Query().Get("limit_lends") != strconv.Itoa(scriptLendsLimit) {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(`[{"rate":"73.0","amount_lent":"1000.0","timestamp":1444264307},{"rate":"36.5","amount_lent":"1000.0","timestamp":1444264000}]`))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { exchange.APIURL = url }(exchange.APIURL)
+	exchange.APIURL = srv.URL + "/v1/"
+
+	m := testMarket()
+	m.Lendbook.Asks[0].FRR = true
+	m.Offers = nil
+
+	conf := Config{MaxActiveAmount: -1, ExtAPI: exchange.NewBitfinexExt("key", "secret")}
+	json.Unmarshal([]byte(`{"Rate": "max(FRR + 0.002, lends(75, 50))"}`), &conf.Strategy.Script)
+
+	actions, err := strategyScript(conf, m)
+	if err != nil {
+		t.Fatal("Strategy failed: " + err.Error())
+	}
+
+	if len(actions) != 1 || actions[0].YearlyRate != exchange.RateDecimal(73) || actions[0].Amount != exchange.MustDecimal("400") {
+		t.Errorf("Returned wrong actions %v", actions)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright Andrius Sutas BitfinexLendingBot [at] motoko [dot] sutas [dot] eu
+
+// Package strategy decides which lend offers to place and cancel on an account's market
+package strategy
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/eAndrius/BitfinexLendingBot/exchange"
+)
+
+// Conf ...
+type Conf struct {
+	Active      string
+	Allocations []AllocationConf // Splits the wallet between strategies, instead of Active
+	MarginBot   MarginBotConf
+	CascadeBot  CascadeBotConf
+	Harmonia    HarmoniaConf
+	Plugin      PluginConf
+	Script      ScriptConf
+}
+
+// Config is what a strategy runs with besides the market
+type Config struct {
+	Account         string
+	Strategy        Conf
+	MaxActiveAmount float64               // Negative for no limit
+	ExtAPI          *exchange.BitfinexExt // Exchange data not in the market, e.g. recent lends
+	Owned           *OwnedOffers          // Offers created by each strategy, none if nil
+	Explain         bool                  // Record how each action was derived
+	Log             *log.Logger           // Where progress is logged, the standard logger if nil
+}
+
+func (c Config) logger() *log.Logger {
+	if c.Log == nil {
+		return log.Default()
+	}
+
+	return c.Log
+}
+
+// Func plans a strategy's actions on the market
+type Func func(Config, Market) (PlanActions, error)
+
+// Run runs the active strategy, or every allocated one, on the market.
+// Offers placed manually, or by other allocated strategies, are never touched.
+func Run(conf Config, m Market) (actions PlanActions, err error) {
+	owned := conf.owned()
+	owned.reconcile(m)
+	conf.Owned = owned
+
+	if len(conf.Strategy.Allocations) > 0 {
+		return planAllocations(conf, m)
+	}
+
+	strategy, err := Lookup(conf.Strategy.Active)
+	if err != nil {
+		return
+	}
+
+	owned.handOver(conf.Strategy.Active)
+
+	sub := m
+	sub.Offers = owned.offers(m, conf.Strategy.Active)
+	sub, err = withoutOwnOffers(sub)
+	if err != nil {
+		return
+	}
+
+	actions, err = strategy(conf, sub)
+	if err != nil {
+		return
+	}
+
+	return ownActions(actions, sub.Offers), nil
+}
+
+// Lookup returns the strategy with the name
+func Lookup(name string) (Func, error) {
+	switch strings.ToLower(name) {
+	case "marginbot":
+		return strategyMarginBot, nil
+	case "cascadebot":
+		return strategyCascadeBot, nil
+	case "harmonia":
+		return strategyHarmonia, nil
+	case "plugin":
+		return strategyPlugin, nil
+	case "script":
+		return strategyScript, nil
+	}
+
+	return nil, errors.New("Undefined strategy")
+}
+
+// Plan actions
+const (
+	ActionCancel    = "cancel"
+	ActionCancelAll = "cancel_all"
+	ActionLend      = "lend"
+)
+
+// PlanAction ...
+type PlanAction struct {
+	Action     string
+	Strategy   string `json:",omitempty"` // Allocation the action belongs to
+	OfferID    int    `json:",omitempty"`
+	Amount     exchange.Decimal
+	YearlyRate exchange.Decimal
+	Period     int `json:",omitempty"`
+
+	// How the strategy derived the action, only with --explain
+	Explain Derivation `json:",omitempty"`
+}
+
+// PlanActions ...
+type PlanActions []PlanAction
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}